@@ -0,0 +1,140 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"isup/redact"
+	"isup/scheduler"
+)
+
+// Server serves a JSON API of the status published by the scheduler. The
+// only changes that can be made are to the silences, and only by requests
+// with the token. Without a token the API is read-only.
+type Server struct {
+	mutex    sync.RWMutex
+	status   *scheduler.Status
+	silences *scheduler.Silences
+	token    string
+	mux      *http.ServeMux
+}
+
+func NewServer(silences *scheduler.Silences, token string) *Server {
+	s := &Server{
+		silences: silences,
+		token:    token,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/api/jobs", s.jobs)
+	s.mux.HandleFunc("/api/jobs/", s.job)
+	s.mux.HandleFunc("/api/alerters", s.alerters)
+	s.mux.HandleFunc("/api/silences", s.silencesHandler)
+	s.mux.HandleFunc("/api/silences/", s.silence)
+
+	return s
+}
+
+// SetStatus replaces the Status that is served, such as after a reload
+func (s *Server) SetStatus(status *scheduler.Status) {
+	s.mutex.Lock()
+	s.status = status
+	s.mutex.Unlock()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost, http.MethodDelete:
+		if !strings.HasPrefix(r.URL.Path, "/api/silences") {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if !s.authorized(w, r) {
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized returns whether the request has the token that allows changes,
+// writing an error if it doesn't
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.token == "" {
+		writeError(w, http.StatusForbidden, "Changes through the API are disabled")
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "Invalid token")
+		return false
+	}
+	return true
+}
+
+func (s *Server) getStatus() *scheduler.Status {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.status
+}
+
+func (s *Server) jobs(w http.ResponseWriter, r *http.Request) {
+	status := s.getStatus()
+	if status == nil {
+		writeJSON(w, http.StatusOK, []scheduler.JobStatus{})
+		return
+	}
+	writeJSON(w, http.StatusOK, status.Jobs())
+}
+
+func (s *Server) job(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/jobs/")
+
+	status := s.getStatus()
+	if status != nil {
+		if job, ok := status.Job(name); ok {
+			writeJSON(w, http.StatusOK, job)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Job not found")
+}
+
+func (s *Server) alerters(w http.ResponseWriter, r *http.Request) {
+	status := s.getStatus()
+	if status == nil {
+		writeJSON(w, http.StatusOK, []scheduler.AlerterStatus{})
+		return
+	}
+	writeJSON(w, http.StatusOK, status.Alerters())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not write API response")
+		writeError(w, http.StatusInternalServerError, "Could not encode response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(append(redact.Bytes(data), '\n'))
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not write API response")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{
+		"error": msg,
+	})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"isup/scheduler"
+)
+
+// maxSilenceSize is the largest request body accepted when adding a silence
+const maxSilenceSize = 1 << 20
+
+// silencesHandler lists the silences, or adds one
+func (s *Server) silencesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		// Only accept JSON, which a cross-site form post can't send
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+
+		var silence scheduler.Silence
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSilenceSize))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&silence)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = s.silences.Add(&silence)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Info().
+			Str("silence", silence.ID).
+			Str("created_by", silence.CreatedBy).
+			Str("comment", silence.Comment).
+			Time("ends_at", silence.EndsAt).
+			Msg("Silence added")
+		writeJSON(w, http.StatusCreated, silence)
+	case http.MethodDelete:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	default:
+		writeJSON(w, http.StatusOK, s.silences.List())
+	}
+}
+
+// silence removes a silence
+func (s *Server) silence(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/silences/")
+
+	if r.Method != http.MethodDelete {
+		for _, silence := range s.silences.List() {
+			if silence.ID == id {
+				writeJSON(w, http.StatusOK, silence)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Silence not found")
+		return
+	}
+
+	found, err := s.silences.Remove(id)
+	if errors.Is(err, scheduler.ErrStaticSilence) {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "Silence not found")
+		return
+	}
+	log.Info().Str("silence", id).Msg("Silence removed")
+	w.WriteHeader(http.StatusNoContent)
+}
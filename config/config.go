@@ -1,24 +1,44 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
 
+	"isup/events"
+	"isup/history"
 	"isup/scheduler"
+	"isup/statuspage"
+	"isup/tracing"
 )
 
 type Config struct {
-	filename string
-	Schedule scheduler.Schedule
-	Alerters map[string]*scheduler.Alerter
-	Client   *Client
+	filename    string
+	Secrets     scheduler.Secrets
+	Schedule    scheduler.Schedule
+	Alerters    map[string]*scheduler.Alerter
+	Escalations map[string]*scheduler.Escalation
+	Routes      []*scheduler.Route
+	Silences    []*scheduler.Silence
+	Outbox      *scheduler.Outbox
+	Client      *Client
+	StatusPage  *statuspage.StatusPage `yaml:"status_page"`
+	History     *history.History
+	Tracing     *tracing.Tracing
+	Events      *events.Events
 }
 
 func (c *Config) Check() (*Config, error) {
 	log.Info().Msg("Checking config")
 
+	err := c.Secrets.Check()
+	if err != nil {
+		return nil, err
+	}
+
 	alerterNames := make([]string, 0, len(c.Alerters))
 	for n, a := range c.Alerters {
 		err := a.Check()
@@ -28,7 +48,41 @@ func (c *Config) Check() (*Config, error) {
 		alerterNames = append(alerterNames, n)
 	}
 
-	err := c.Schedule.Check(alerterNames)
+	if c.Outbox != nil {
+		err = c.Outbox.Check()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	validAlerters := alerterNames
+	for n, e := range c.Escalations {
+		if _, ok := c.Alerters[n]; ok {
+			return nil, fmt.Errorf("Escalation: '%s' has the same name as an Alerter", n)
+		}
+		err := e.Check(alerterNames)
+		if err != nil {
+			return nil, fmt.Errorf("Escalation: '%s' %w", n, err)
+		}
+		validAlerters = append(validAlerters, n)
+	}
+
+	for _, r := range c.Routes {
+		err := r.Check(validAlerters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, s := range c.Silences {
+		err := s.Check()
+		if err != nil {
+			return nil, fmt.Errorf("Silence: %d %w", i, err)
+		}
+	}
+
+	ctx := context.WithValue(context.Background(), "secrets", c.Secrets)
+	err = c.Schedule.Check(ctx, validAlerters)
 	if err != nil {
 		return nil, err
 	}
@@ -42,6 +96,35 @@ func (c *Config) Check() (*Config, error) {
 		}
 	}
 
+	if c.History != nil {
+		err = c.History.Check()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Tracing != nil {
+		err = c.Tracing.Check()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Events != nil {
+		err = c.Events.Check()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.StatusPage == nil {
+		c.StatusPage = &statuspage.StatusPage{}
+	}
+	err = c.StatusPage.Check()
+	if err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -51,8 +134,10 @@ func (c *Config) Reload() (*Config, error) {
 		return nil, err
 	}
 
-	cfg := &Config{}
-	err = yaml.UnmarshalStrict([]byte(data), cfg)
+	cfg := &Config{
+		filename: c.filename,
+	}
+	err = yaml.UnmarshalStrict(expandEnv(data), cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -72,3 +157,29 @@ func LoadConfig(filename string) (*Config, error) {
 
 	return cfg.Reload()
 }
+
+// LoadHistory reads only the history from the config file, without checking
+// the rest of the config or reading its secrets, for commands that just read
+// the history. It returns nil if there is no history.
+func LoadHistory(filename string) (*history.History, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := struct {
+		History *history.History
+	}{}
+	err = yaml.Unmarshal(expandEnv(data), &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.History != nil {
+		err = cfg.History.Check()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfg.History, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Matches ${VAR}, ${VAR:-default} and the escaped form $${
+var envPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} with the value of the environment variable VAR.
+// ${VAR:-default} uses default when VAR is unset or empty, and $${ can be used
+// to write a literal ${.
+func expandEnv(data []byte) []byte {
+	return envPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := envPattern.FindSubmatch(match)
+		if groups[1] == nil {
+			return []byte("${")
+		}
+
+		name := string(groups[1])
+		value, exist := os.LookupEnv(name)
+		if value == "" && groups[2] != nil {
+			return groups[3]
+		}
+		if !exist {
+			log.Warn().Str("variable", name).Msg("Config variable is not set")
+		}
+		return []byte(value)
+	})
+}
@@ -0,0 +1,219 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"isup/redact"
+	"isup/scheduler"
+)
+
+// SchemaVersion is increased whenever a change to Event could break consumers
+const SchemaVersion = 1
+
+// Events configures where result events are written
+type Events struct {
+	Output *string
+	Path   *string
+}
+
+// Event is a single line written to the sink. Its fields are a stable schema
+// unlike the log messages.
+type Event struct {
+	Version    int               `json:"version"`
+	Type       string            `json:"type"`
+	Time       time.Time         `json:"time"`
+	Job        string            `json:"job"`
+	Test       string            `json:"test,omitempty"`
+	State      string            `json:"state,omitempty"`
+	From       string            `json:"from,omitempty"`
+	To         string            `json:"to,omitempty"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Duration   *float64          `json:"duration,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Values     map[string]string `json:"values,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+	Failed     []string          `json:"failed,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+func (e *Events) Check() error {
+	if e.Output == nil {
+		output := "stdout"
+		e.Output = &output
+	}
+
+	switch *e.Output {
+	case "stdout":
+	case "file", "unix":
+		if e.Path == nil {
+			return fmt.Errorf("Events.Path cannot be empty for '%s' output", *e.Output)
+		}
+	default:
+		return fmt.Errorf("Events.Output '%s' isn't valid", *e.Output)
+	}
+
+	return nil
+}
+
+// Sink is a Listener that writes an Event for the result of every Job and Test,
+// and for every change in the state of a Job
+type Sink struct {
+	config *Events
+	events chan Event
+	out    io.WriteCloser
+}
+
+func NewSink(config *Events) *Sink {
+	return &Sink{
+		config: config,
+		events: make(chan Event, 1000),
+	}
+}
+
+func (s *Sink) JobFinished(name string, job *scheduler.Job, result scheduler.JobResult) {
+	for n, t := range result.Tests {
+		duration := t.Duration.Seconds()
+		event := Event{
+			Type:       "test_result",
+			Time:       result.Time,
+			Job:        name,
+			Test:       n,
+			State:      t.State.String(),
+			StatusCode: t.StatusCode,
+			Duration:   &duration,
+			Values:     t.Values,
+		}
+		if t.Err != nil {
+			event.Error = t.Err.Error()
+		}
+		s.send(event)
+	}
+
+	duration := result.Duration.Seconds()
+	event := Event{
+		Type:     "job_result",
+		Time:     result.Time,
+		Job:      name,
+		State:    result.State.String(),
+		Duration: &duration,
+	}
+	if result.Err != nil {
+		event.Error = result.Err.Error()
+	}
+	s.send(event)
+
+	if t := result.Transition; t != nil && result.State != result.PrevState {
+		s.send(Event{
+			Type:       "transition",
+			Time:       t.Time,
+			Job:        name,
+			From:       t.From.String(),
+			To:         t.To.String(),
+			Expression: t.Expression,
+			Failed:     t.Failed,
+			Reason:     t.Reason(),
+			Errors:     t.Errors,
+		})
+	}
+}
+
+func (s *Sink) JobStopped(name string) {
+}
+
+func (s *Sink) send(e Event) {
+	e.Version = SchemaVersion
+
+	select {
+	case s.events <- e:
+	default:
+		log.Warn().
+			Str("job", e.Job).
+			Str("type", e.Type).
+			Msg("Event sink is full, dropping event")
+	}
+}
+
+// Run writes the events to the output until the context is done
+func (s *Sink) Run(ctx context.Context) {
+	defer s.close()
+
+	for {
+		select {
+		case e := <-s.events:
+			err := s.write(e)
+			if err != nil {
+				log.Warn().
+					Str("output", *s.config.Output).
+					Err(err).
+					Msg("Could not write event")
+				s.close()
+			}
+		case <-ctx.Done():
+			// Write anything that is left before stopping
+			for len(s.events) > 0 {
+				err := s.write(<-s.events)
+				if err != nil {
+					return
+				}
+			}
+			return
+		}
+	}
+}
+
+func (s *Sink) write(e Event) error {
+	if s.out == nil {
+		out, err := s.open()
+		if err != nil {
+			return err
+		}
+		s.out = out
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.out.Write(append(redact.Bytes(data), '\n'))
+	return err
+}
+
+func (s *Sink) open() (io.WriteCloser, error) {
+	switch *s.config.Output {
+	case "file":
+		err := os.MkdirAll(filepath.Dir(*s.config.Path), 0744)
+		if err != nil {
+			return nil, err
+		}
+		return os.OpenFile(*s.config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	case "unix":
+		return net.Dial("unix", *s.config.Path)
+	}
+	return nopCloser{os.Stdout}, nil
+}
+
+func (s *Sink) close() {
+	if s.out != nil {
+		s.out.Close()
+		s.out = nil
+	}
+}
+
+// nopCloser stops stdout from being closed
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Read returns the stored results of every Job between from and to, sorted by
+// time
+func Read(path string, from, to time.Time) (map[string][]JobRecord, error) {
+	jobs := make(map[string][]JobRecord)
+	err := Walk(path, from, to, func(r JobRecord) error {
+		jobs[r.Job] = append(jobs[r.Job], r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Walk calls fn with each stored result between from and to, one Job at a time
+// sorted by time, without holding them all in memory
+func Walk(path string, from, to time.Time, fn func(JobRecord) error) error {
+	db, err := bolt.Open(path, 0644, &bolt.Options{
+		ReadOnly: true,
+		Timeout:  10 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fromKey := timeKey(from)
+	toKey := timeKey(to)
+
+	return db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(name, _ []byte) error {
+			c := b.Bucket(name).Cursor()
+			for k, v := c.Seek(fromKey); k != nil && bytes.Compare(k, toKey) <= 0; k, v = c.Next() {
+				var r JobRecord
+				err := json.Unmarshal(v, &r)
+				if err != nil {
+					return err
+				}
+				err = fn(r)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
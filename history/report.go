@@ -0,0 +1,117 @@
+package history
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"isup/scheduler"
+)
+
+// Report summarises the availability and latency of a Job over a period. The
+// Job is down while it is Alerting or has no data because a test couldn't be
+// run, and each state lasts until the next result.
+type Report struct {
+	Job          string   `json:"job"`
+	Runs         int      `json:"runs"`
+	Availability float64  `json:"availability"`
+	NoData       float64  `json:"no_data"`
+	Incidents    int      `json:"incidents"`
+	MTTR         *float64 `json:"mttr"`
+	MTBF         *float64 `json:"mtbf"`
+	LatencyP50   float64  `json:"latency_p50"`
+	LatencyP95   float64  `json:"latency_p95"`
+	LatencyP99   float64  `json:"latency_p99"`
+}
+
+// NewReport creates a Report from the results of a Job, sorted by time, that
+// were recorded before the end of the period. Time with no data that isn't
+// down doesn't count towards the availability.
+func NewReport(job string, records []JobRecord, end time.Time) Report {
+	report := Report{
+		Job:  job,
+		Runs: len(records),
+	}
+	if len(records) == 0 {
+		return report
+	}
+
+	var downtime, repairs, noData, unknown time.Duration
+	var resolved int
+	var incidentStart time.Time
+	down := false
+	noDataState := scheduler.NoDataState.String()
+
+	for i, r := range records {
+		until := end
+		if i+1 < len(records) {
+			until = records[i+1].Time
+		}
+		if r.State == noDataState {
+			noData += until.Sub(r.Time)
+			if !r.Down() {
+				unknown += until.Sub(r.Time)
+			}
+		}
+
+		if r.Down() && !down {
+			down = true
+			incidentStart = r.Time
+			report.Incidents += 1
+		} else if !r.Down() && down {
+			down = false
+			downtime += r.Time.Sub(incidentStart)
+			repairs += r.Time.Sub(incidentStart)
+			resolved += 1
+		}
+	}
+	if down {
+		// The incident is still ongoing at the end of the period
+		downtime += end.Sub(incidentStart)
+	}
+
+	period := end.Sub(records[0].Time)
+	observed := period - unknown
+	if observed > 0 {
+		report.Availability = 1 - downtime.Seconds()/observed.Seconds()
+	} else if !down {
+		report.Availability = 1
+	}
+	if period > 0 {
+		report.NoData = noData.Seconds() / period.Seconds()
+	}
+
+	if resolved > 0 {
+		mttr := repairs.Seconds() / float64(resolved)
+		report.MTTR = &mttr
+	}
+	if report.Incidents > 0 {
+		mtbf := (observed - downtime).Seconds() / float64(report.Incidents)
+		report.MTBF = &mtbf
+	}
+
+	latencies := make([]float64, 0, len(records))
+	for _, r := range records {
+		if r.State != noDataState {
+			latencies = append(latencies, r.Duration)
+		}
+	}
+	sort.Float64s(latencies)
+	report.LatencyP50 = percentile(latencies, 50)
+	report.LatencyP95 = percentile(latencies, 95)
+	report.LatencyP99 = percentile(latencies, 99)
+
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,33 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewReportUnreachable(t *testing.T) {
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	unreachable := map[string]TestRecord{
+		"health": {State: "No_Data", Error: "connection refused"},
+	}
+	records := []JobRecord{
+		{Time: start, State: "Ok"},
+		{Time: start.Add(time.Hour), State: "No_Data", Tests: unreachable},
+		{Time: start.Add(2 * time.Hour), State: "Ok"},
+		{Time: start.Add(3 * time.Hour), State: "No_Data", Tests: unreachable},
+	}
+
+	r := NewReport("web", records, start.Add(4*time.Hour))
+	if r.Availability != 0.5 {
+		t.Errorf("Availability = %f, want 0.5", r.Availability)
+	}
+	if r.NoData != 0.5 {
+		t.Errorf("NoData = %f, want 0.5", r.NoData)
+	}
+	if r.Incidents != 2 {
+		t.Errorf("Incidents = %d, want 2", r.Incidents)
+	}
+	if r.MTTR == nil || *r.MTTR != time.Hour.Seconds() {
+		t.Errorf("MTTR = %v, want %f", r.MTTR, time.Hour.Seconds())
+	}
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"isup/scheduler"
+)
+
+var silencesBucket = []byte("silences")
+
+// SilenceStore keeps the silences added through the API in the history
+// database so that they are kept when isup is restarted
+type SilenceStore struct {
+	config *History
+}
+
+func NewSilenceStore(config *History) *SilenceStore {
+	return &SilenceStore{config: config}
+}
+
+// Load returns the stored silences, or none if the database doesn't exist yet
+func (s *SilenceStore) Load() ([]*scheduler.Silence, error) {
+	_, err := os.Stat(*s.config.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := bolt.Open(*s.config.Path, 0644, &bolt.Options{
+		ReadOnly: true,
+		Timeout:  10 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	silences := make([]*scheduler.Silence, 0)
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(silencesBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var silence scheduler.Silence
+			err := json.Unmarshal(v, &silence)
+			if err != nil {
+				return err
+			}
+			silences = append(silences, &silence)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+// Save replaces the stored silences
+func (s *SilenceStore) Save(silences []*scheduler.Silence) error {
+	err := os.MkdirAll(filepath.Dir(*s.config.Path), 0744)
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(*s.config.Path, 0644, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(silencesBucket) != nil {
+			err := tx.DeleteBucket(silencesBucket)
+			if err != nil {
+				return err
+			}
+		}
+		b, err := tx.CreateBucket(silencesBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, silence := range silences {
+			data, err := json.Marshal(silence)
+			if err != nil {
+				return err
+			}
+			err = b.Put([]byte(silence.ID), data)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
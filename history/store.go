@@ -0,0 +1,249 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	bolt "go.etcd.io/bbolt"
+
+	"isup/redact"
+	"isup/scheduler"
+)
+
+var jobsBucket = []byte("jobs")
+
+// History configures where the results of every Job are stored and for how
+// long
+type History struct {
+	Path      *string
+	Retention *time.Duration
+	Flush     *time.Duration `yaml:"flush_interval"`
+}
+
+// JobRecord is the stored result of a single run of a Job
+type JobRecord struct {
+	Time     time.Time             `json:"time"`
+	Job      string                `json:"job"`
+	State    string                `json:"state"`
+	Duration float64               `json:"duration"`
+	Error    string                `json:"error,omitempty"`
+	Tests    map[string]TestRecord `json:"tests"`
+}
+
+// TestRecord is the stored result of a single run of a Test
+type TestRecord struct {
+	State      string            `json:"state"`
+	StatusCode int               `json:"status_code"`
+	Duration   float64           `json:"duration"`
+	Error      string            `json:"error,omitempty"`
+	Values     map[string]string `json:"values,omitempty"`
+}
+
+// Down returns whether the Job was down, in the same way as
+// scheduler.JobResult.Down
+func (r JobRecord) Down() bool {
+	if r.State == scheduler.AlertingState.String() {
+		return true
+	}
+	if r.State != scheduler.NoDataState.String() {
+		return false
+	}
+	for _, t := range r.Tests {
+		if t.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *History) Check() error {
+	if h.Path == nil {
+		return fmt.Errorf("History.Path cannot be empty")
+	}
+	if h.Retention == nil {
+		retention := 90 * 24 * time.Hour
+		h.Retention = &retention
+	}
+	if h.Flush == nil {
+		flush := 5 * time.Second
+		h.Flush = &flush
+	}
+	return nil
+}
+
+// Store is a Listener that writes the result of every Job to the database.
+// Results are written in batches, and the database is only held open while
+// writing so that it can be read by other processes.
+type Store struct {
+	config  *History
+	records chan JobRecord
+}
+
+func NewStore(config *History) *Store {
+	return &Store{
+		config:  config,
+		records: make(chan JobRecord, 1000),
+	}
+}
+
+func (s *Store) JobFinished(name string, job *scheduler.Job, result scheduler.JobResult) {
+	record := JobRecord{
+		Time:     result.Time,
+		Job:      name,
+		State:    result.State.String(),
+		Duration: result.Duration.Seconds(),
+		Tests:    make(map[string]TestRecord, len(result.Tests)),
+	}
+	if result.Err != nil {
+		record.Error = redact.String(result.Err.Error())
+	}
+	for n, t := range result.Tests {
+		test := TestRecord{
+			State:      t.State.String(),
+			StatusCode: t.StatusCode,
+			Duration:   t.Duration.Seconds(),
+			Values:     redact.Map(t.Values),
+		}
+		if t.Err != nil {
+			test.Error = redact.String(t.Err.Error())
+		}
+		record.Tests[n] = test
+	}
+
+	select {
+	case s.records <- record:
+	default:
+		log.Warn().
+			Str("job", name).
+			Msg("History is full, dropping result")
+	}
+}
+
+// JobStopped keeps the history of the Job
+func (s *Store) JobStopped(name string) {
+}
+
+// Run writes the results to the database until the context is done
+func (s *Store) Run(ctx context.Context) {
+	err := os.MkdirAll(filepath.Dir(*s.config.Path), 0744)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not create history directory")
+	}
+
+	pending := make([]JobRecord, 0)
+	lastPrune := time.Time{}
+	ticker := time.NewTicker(*s.config.Flush)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r := <-s.records:
+			pending = append(pending, r)
+			continue
+		case <-ticker.C:
+		case <-ctx.Done():
+			// Write anything that is left before stopping
+			for len(s.records) > 0 {
+				pending = append(pending, <-s.records)
+			}
+		}
+
+		prune := time.Since(lastPrune) > time.Hour
+		if len(pending) > 0 || prune {
+			err := s.write(pending, prune)
+			if err != nil {
+				log.Warn().Err(err).Int("no_results", len(pending)).Msg("Could not write history")
+			} else {
+				pending = pending[:0]
+				if prune {
+					lastPrune = time.Now()
+				}
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *Store) write(records []JobRecord, prune bool) error {
+	db, err := bolt.Open(*s.config.Path, 0644, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		jobs, err := tx.CreateBucketIfNotExists(jobsBucket)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range records {
+			b, err := jobs.CreateBucketIfNotExists([]byte(r.Job))
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			err = b.Put(timeKey(r.Time), data)
+			if err != nil {
+				return err
+			}
+		}
+
+		if prune {
+			return s.prune(jobs)
+		}
+		return nil
+	})
+}
+
+// prune deletes every result older than the retention
+func (s *Store) prune(jobs *bolt.Bucket) error {
+	cutoff := timeKey(time.Now().Add(-*s.config.Retention))
+	empty := make([][]byte, 0)
+
+	err := jobs.ForEach(func(name, _ []byte) error {
+		b := jobs.Bucket(name)
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.First() {
+			err := c.Delete()
+			if err != nil {
+				return err
+			}
+		}
+		if k, _ := c.First(); k == nil {
+			empty = append(empty, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range empty {
+		err := jobs.DeleteBucket(name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeKey returns a key that sorts results by time
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
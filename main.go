@@ -2,20 +2,30 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/natefinch/lumberjack.v2"
 
+	"isup/api"
 	"isup/config"
+	"isup/events"
+	"isup/history"
+	"isup/redact"
 	"isup/scheduler"
+	"isup/statuspage"
+	"isup/tracing"
 )
 
 func configureLogging(c *cli.Context) error {
@@ -58,24 +68,86 @@ func configureLogging(c *cli.Context) error {
 		return err
 	}
 
-	multiwriter := io.MultiWriter(writers...)
-	log.Logger = zerolog.New(multiwriter).Level(level).With().Timestamp().Logger()
+	log.Logger = zerolog.New(redact.NewWriter(io.MultiWriter(writers...))).Level(level).With().Timestamp().Logger()
 
 	return nil
 }
 
+// services are shared by every scheduler that is started
+type services struct {
+	api      *api.Server
+	page     *statuspage.Page
+	uptime   *statuspage.Uptime
+	silences *scheduler.Silences
+}
+
+func newServices(c *cli.Context) *services {
+	uptime := statuspage.NewUptime()
+	silences := scheduler.NewSilences()
+	return &services{
+		api:      api.NewServer(silences, c.String("http.token")),
+		page:     statuspage.NewPage(uptime),
+		uptime:   uptime,
+		silences: silences,
+	}
+}
+
+func startServer(c *cli.Context, svc *services) {
+	address := c.String("http.listen")
+	if address == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/api/", svc.api)
+	mux.Handle("/status", svc.page)
+
+	go func() {
+		log.Info().Str("address", address).Msg("Starting HTTP server")
+		err := http.ListenAndServe(address, mux)
+		if err != nil {
+			log.Fatal().Err(err).Msg("HTTP server error")
+		}
+	}()
+}
+
+// configFile returns the config file, which is only required by the commands
+// that load it rather than talking to a running instance
+func configFile(c *cli.Context) (string, error) {
+	configfile := c.String("config")
+	if configfile == "" {
+		return "", fmt.Errorf("Required flag \"config\" not set")
+	}
+	return configfile, nil
+}
+
 func run(c *cli.Context) error {
-	err := configureLogging(c)
+	configfile, err := configFile(c)
+	if err != nil {
+		return err
+	}
+
+	err = configureLogging(c)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not configure logging")
 	}
 
-	configfile := c.String("config")
 	log.Info().Str("filename", configfile).Msg("Loading config")
 	cfg, err := config.LoadConfig(configfile)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Config error")
 	}
+	redact.SetSecrets(cfg.Secrets.Values())
+
+	svc := newServices(c)
+	if cfg.History != nil {
+		err := svc.uptime.Load(*cfg.History.Path, time.Now())
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not load uptime from the history")
+		}
+	}
+	startServer(c, svc)
 
 	log.Info().Msg("Initialisation complete")
 
@@ -85,7 +157,7 @@ func run(c *cli.Context) error {
 	signal.Notify(sigReload, syscall.SIGUSR1)
 
 	var cancel context.CancelFunc
-	cancel = startScheduler(cfg)
+	cancel = startScheduler(cfg, svc)
 
 	for {
 		select {
@@ -100,25 +172,115 @@ func run(c *cli.Context) error {
 				log.Error().Err(err).Msg("Could not reload config")
 			} else {
 				cfg = newCfg
+				redact.SetSecrets(cfg.Secrets.Values())
 				log.Info().Msg("Config reloaded successfully")
 				cancel()
-				cancel = startScheduler(cfg)
+				cancel = startScheduler(cfg, svc)
 			}
 		}
 	}
 }
 
-func startScheduler(cfg *config.Config) context.CancelFunc {
+func startScheduler(cfg *config.Config, svc *services) context.CancelFunc {
+	svc.silences.SetStatic(cfg.Silences)
+	var silenceStore scheduler.SilenceStore
+	if cfg.History != nil {
+		silenceStore = history.NewSilenceStore(cfg.History)
+	}
+	err := svc.silences.SetStore(silenceStore)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not load stored silences")
+	}
+	status := scheduler.NewStatus(cfg.Alerters, svc.silences)
+	svc.api.SetStatus(status)
+	svc.page.Set(cfg.StatusPage, status)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx = context.WithValue(ctx, "http.client", cfg.Client.HttpClient())
+	ctx = context.WithValue(ctx, "secrets", cfg.Secrets)
+	if cfg.Outbox != nil {
+		ctx = context.WithValue(ctx, "outbox", cfg.Outbox)
+	}
+	shutdownTracing, err := tracing.Start(ctx, cfg.Tracing)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not start tracing")
+		shutdownTracing, _ = tracing.Start(ctx, nil)
+	}
+
+	// Wait for results to be written, and for the router to close the outbox,
+	// before the scheduler is stopped
+	var wg sync.WaitGroup
+	stop := func() {
+		cancel()
+		wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := shutdownTracing(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not flush traces")
+		}
+	}
 
 	r := scheduler.NewRouter()
 	r.Alerters = cfg.Alerters
+	r.Escalations = cfg.Escalations
+	r.Routes = cfg.Routes
+	r.Silences = svc.silences
+
+	listeners := []scheduler.Listener{status, svc.uptime, r}
+	if cfg.History != nil {
+		store := history.NewStore(cfg.History)
+		listeners = append(listeners, store)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Run(ctx)
+		}()
+	}
+	if cfg.Events != nil {
+		sink := events.NewSink(cfg.Events)
+		listeners = append(listeners, sink)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink.Run(ctx)
+		}()
+	}
+	ctx = context.WithValue(ctx, "listeners", listeners)
 
 	go cfg.Schedule.Run(ctx, r.Alerts)
-	go r.Run(ctx)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.Run(ctx)
+	}()
+	go svc.page.Export(ctx)
 
-	return cancel
+	return stop
+}
+
+func exportStatus(c *cli.Context) error {
+	resp, err := http.Get(c.String("url"))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	out := os.Stdout
+	if c.String("output") != "-" {
+		out, err = os.Create(c.String("output"))
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	return err
 }
 
 func main() {
@@ -126,11 +288,21 @@ func main() {
 		Flags: []cli.Flag{
 			// Config File Options
 			&cli.StringFlag{
-				Name:     "config",
-				Aliases:  []string{"c"},
-				EnvVars:  []string{"ISUP_CONFIG"},
-				Required: true,
-				Usage:    "Load config from `FILE` (required)",
+				Name:    "config",
+				Aliases: []string{"c"},
+				EnvVars: []string{"ISUP_CONFIG"},
+				Usage:   "Load config from `FILE` (required to run isup, and for report without --history)",
+			},
+			// HTTP Server Options
+			&cli.StringFlag{
+				Name:    "http.listen",
+				EnvVars: []string{"ISUP_HTTP_LISTEN"},
+				Usage:   "Serve metrics, the API and the status page on `ADDRESS`, e.g. :9090",
+			},
+			&cli.StringFlag{
+				Name:    "http.token",
+				EnvVars: []string{"ISUP_HTTP_TOKEN"},
+				Usage:   "Allow silences to be changed through the API with `TOKEN`",
 			},
 			//Logging Options
 			&cli.StringFlag{
@@ -188,6 +360,130 @@ func main() {
 			},
 		},
 		Action: run,
+		Commands: []*cli.Command{
+			{
+				Name:   "report",
+				Usage:  "Report the availability of jobs from the history",
+				Action: report,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "history",
+						Usage: "Read the history database at `FILE` instead of the one in the config",
+					},
+					&cli.StringFlag{
+						Name:  "from",
+						Value: "720h",
+						Usage: "Report from `TIME`, either RFC3339 or a duration ago",
+					},
+					&cli.StringFlag{
+						Name:  "to",
+						Value: "now",
+						Usage: "Report until `TIME`, either RFC3339 or a duration ago",
+					},
+					&cli.StringSliceFlag{
+						Name:  "job",
+						Usage: "Only report on `JOB`, can be repeated",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "table",
+						Usage: "Output as `FORMAT`; table, csv or json",
+					},
+				},
+			},
+			{
+				Name:   "export-status",
+				Usage:  "Export the status page of a running instance to a static HTML file",
+				Action: exportStatus,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "url",
+						EnvVars: []string{"ISUP_EXPORT_URL"},
+						Value:   "http://localhost:9090/status",
+						Usage:   "Fetch the status page from `URL`",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Value:   "-",
+						Usage:   "Write the status page to `FILE`, - for stdout",
+					},
+				},
+			},
+			{
+				Name:  "silence",
+				Usage: "Manage the silences of a running instance",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "url",
+						EnvVars: []string{"ISUP_API_URL"},
+						Value:   "http://localhost:9090",
+						Usage:   "Use the API of the instance at `URL`",
+					},
+					&cli.StringFlag{
+						Name:    "token",
+						EnvVars: []string{"ISUP_HTTP_TOKEN"},
+						Usage:   "Authenticate to the API with `TOKEN`",
+					},
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:   "add",
+						Usage:  "Silence the alerts of the matching jobs",
+						Action: addSilence,
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  "job",
+								Usage: "Silence `JOB`, can be repeated",
+							},
+							&cli.StringSliceFlag{
+								Name:  "match",
+								Usage: "Silence jobs with the label `NAME=VALUE`, can be repeated",
+							},
+							&cli.StringSliceFlag{
+								Name:  "match-re",
+								Usage: "Silence jobs with a label matching `NAME=REGEX`, can be repeated",
+							},
+							&cli.StringFlag{
+								Name:  "start",
+								Value: "now",
+								Usage: "Start at `TIME`, either RFC3339 or a duration from now",
+							},
+							&cli.DurationFlag{
+								Name:  "duration",
+								Value: time.Hour,
+								Usage: "Silence for `DURATION`",
+							},
+							&cli.StringFlag{
+								Name:     "comment",
+								Required: true,
+								Usage:    "Why the jobs are silenced (required)",
+							},
+							&cli.StringFlag{
+								Name:    "author",
+								EnvVars: []string{"USER"},
+								Usage:   "Who created the silence",
+							},
+						},
+					},
+					{
+						Name:   "list",
+						Usage:  "List the silences that have not ended",
+						Action: listSilences,
+					},
+					{
+						Name:      "expire",
+						Usage:     "Expire a silence that was added through the API",
+						ArgsUsage: "ID",
+						Action:    expireSilence,
+					},
+				},
+			},
+		},
+	}
+	err := app.Run(os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	app.Run(os.Args)
 }
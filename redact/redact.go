@@ -0,0 +1,119 @@
+// Package redact replaces the values of secrets in everything isup writes
+// outside of the requests and alerts that use them, such as the logs, the API,
+// the status page, events, the history and traces.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/url"
+	"sync"
+)
+
+var (
+	mutex   sync.RWMutex
+	secrets [][]byte
+)
+
+var redacted = []byte("[REDACTED]")
+
+// SetSecrets replaces the values that are redacted
+func SetSecrets(values []string) {
+	s := make([][]byte, 0, len(values))
+	for _, v := range values {
+		s = append(s, forms(v)...)
+	}
+
+	mutex.Lock()
+	secrets = s
+	mutex.Unlock()
+}
+
+// forms returns the ways the value may be written. Log lines are JSON, and may
+// contain JSON such as a response body, so the value is escaped as it would be
+// at each level. The most escaped form is first as it contains the others.
+func forms(v string) [][]byte {
+	escaped := make([][]byte, 0, 6)
+	value := []byte(v)
+	for i := 0; i < 3; i++ {
+		escaped = append([][]byte{value}, escaped...)
+		value = escapeJSON(value)
+	}
+
+	// The value may also be part of a URL or an HTML page
+	for _, e := range []string{url.QueryEscape(v), url.PathEscape(v), escapeHTML(v)} {
+		if e != v {
+			escaped = append(escaped, []byte(e))
+		}
+	}
+	return escaped
+}
+
+// escapeJSON returns the value escaped as it would be in a JSON string
+func escapeJSON(value []byte) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(string(value))
+	escaped := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return escaped[1 : len(escaped)-1]
+}
+
+var htmlTemplate = template.Must(template.New("").Parse("{{.}}"))
+
+// escapeHTML returns the value escaped as it would be by html/template
+func escapeHTML(value string) string {
+	var buf bytes.Buffer
+	_ = htmlTemplate.Execute(&buf, value)
+	return buf.String()
+}
+
+// Bytes returns p with the secrets replaced
+func Bytes(p []byte) []byte {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, s := range secrets {
+		p = bytes.ReplaceAll(p, s, redacted)
+	}
+	return p
+}
+
+// String returns s with the secrets replaced
+func String(s string) string {
+	return string(Bytes([]byte(s)))
+}
+
+// Map returns a copy of m with the secrets replaced in its values
+func Map(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = String(v)
+	}
+	return out
+}
+
+// Writer replaces the secrets in everything written to it before writing it
+// to the underlying Writer. Each write must be complete, such as a log line,
+// for a secret to be found.
+type Writer struct {
+	out io.Writer
+}
+
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{
+		out: out,
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	_, err := w.out.Write(Bytes(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
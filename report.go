@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"isup/config"
+	"isup/history"
+)
+
+// parseTime parses an RFC3339 time, or a duration before now
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" || value == "now" {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func report(c *cli.Context) error {
+	path := c.String("history")
+	if path == "" {
+		configfile, err := configFile(c)
+		if err != nil {
+			return err
+		}
+		h, err := config.LoadHistory(configfile)
+		if err != nil {
+			return err
+		}
+		if h == nil {
+			return fmt.Errorf("History is not configured")
+		}
+		path = *h.Path
+	}
+
+	now := time.Now()
+	from, err := parseTime(c.String("from"), now)
+	if err != nil {
+		return fmt.Errorf("Invalid --from: %w", err)
+	}
+	to, err := parseTime(c.String("to"), now)
+	if err != nil {
+		return fmt.Errorf("Invalid --to: %w", err)
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("--from must be before --to")
+	}
+
+	records, err := history.Read(path, from, to)
+	if err != nil {
+		return err
+	}
+
+	end := to
+	if end.After(now) {
+		end = now
+	}
+
+	jobs := c.StringSlice("job")
+	if len(jobs) == 0 {
+		for j := range records {
+			jobs = append(jobs, j)
+		}
+		sort.Strings(jobs)
+	}
+
+	// A misspelt or unknown job would otherwise be reported as never up
+	missing := make([]string, 0)
+	for _, j := range jobs {
+		if len(records[j]) == 0 {
+			missing = append(missing, j)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("No history in this period for '%s'", strings.Join(missing, "', '"))
+	}
+
+	reports := make([]history.Report, 0, len(jobs))
+	for _, j := range jobs {
+		reports = append(reports, history.NewReport(j, records[j], end))
+	}
+
+	switch c.String("format") {
+	case "table":
+		return writeReportTable(os.Stdout, reports)
+	case "csv":
+		return writeReportCSV(os.Stdout, reports)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	return fmt.Errorf("Unknown format '%s'", c.String("format"))
+}
+
+func formatSeconds(s *float64) string {
+	if s == nil {
+		return "-"
+	}
+	return (time.Duration(*s * float64(time.Second))).Round(time.Second).String()
+}
+
+func formatLatency(s float64) string {
+	return (time.Duration(s * float64(time.Second))).Round(time.Millisecond).String()
+}
+
+func writeReportTable(w io.Writer, reports []history.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tRUNS\tAVAILABILITY\tNO DATA\tINCIDENTS\tMTTR\tMTBF\tP50\tP95\tP99")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%.3f%%\t%.3f%%\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			r.Job,
+			r.Runs,
+			r.Availability*100,
+			r.NoData*100,
+			r.Incidents,
+			formatSeconds(r.MTTR),
+			formatSeconds(r.MTBF),
+			formatLatency(r.LatencyP50),
+			formatLatency(r.LatencyP95),
+			formatLatency(r.LatencyP99),
+		)
+	}
+	return tw.Flush()
+}
+
+func writeReportCSV(w io.Writer, reports []history.Report) error {
+	optional := func(s *float64) string {
+		if s == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*s, 'f', 3, 64)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"job", "runs", "availability", "no_data", "incidents", "mttr", "mtbf", "latency_p50", "latency_p95", "latency_p99"})
+	for _, r := range reports {
+		cw.Write([]string{
+			r.Job,
+			strconv.Itoa(r.Runs),
+			strconv.FormatFloat(r.Availability, 'f', 6, 64),
+			strconv.FormatFloat(r.NoData, 'f', 6, 64),
+			strconv.Itoa(r.Incidents),
+			optional(r.MTTR),
+			optional(r.MTBF),
+			strconv.FormatFloat(r.LatencyP50, 'f', 6, 64),
+			strconv.FormatFloat(r.LatencyP95, 'f', 6, 64),
+			strconv.FormatFloat(r.LatencyP99, 'f', 6, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
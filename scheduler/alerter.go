@@ -2,25 +2,175 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"isup/redact"
+	"isup/testparser"
 )
 
 type Alert struct {
-	Job      string
-	State    State
-	Alerters []string
-	Values   map[string]string
+	Job         string
+	State       State
+	Repeat      bool
+	Alerters    []string
+	Values      map[string]string
+	Labels      map[string]string
+	Maintenance string
+	Tests       map[string]TestResult
+	Transition  *Transition
+	// stopped marks the job as stopped rather than being an alert to send
+	stopped bool
+}
+
+// testsReplacement returns the result of each Test, sorted by name, for use in
+// templates
+func (a Alert) testsReplacement() []map[string]interface{} {
+	names := make([]string, 0, len(a.Tests))
+	for n := range a.Tests {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	tests := make([]map[string]interface{}, 0, len(names))
+	for _, n := range names {
+		t := a.Tests[n]
+		test := map[string]interface{}{
+			"name":             n,
+			"state":            t.State.String(),
+			"ok":               t.State == OkState,
+			"status_code":      t.StatusCode,
+			"duration":         t.Duration.Round(time.Millisecond).String(),
+			"duration_seconds": t.Duration.Seconds(),
+			"error":            "",
+			"values":           t.Values,
+		}
+		if t.Err != nil {
+			test["error"] = t.Err.Error()
+		}
+		tests = append(tests, test)
+	}
+	return tests
 }
 
 type Alerter struct {
-	Default    *bool
-	AlwaysSend *bool
-	Request    *Request
+	Default        *bool
+	AlwaysSend     *bool
+	RepeatInterval *time.Duration `yaml:"repeat_interval"`
+	SendOn         []string       `yaml:"send_on"`
+	Retry          *Retry
+	Ok             *string
+	Response       *Response
+	Request        *Request
+	Slack          *Webhook
+	Teams          *Webhook
+	Discord        *Webhook
+	Email          *Email
+	Exec           *Exec
+	PagerDuty      *PagerDuty `yaml:"pagerduty"`
+	Opsgenie       *Opsgenie
+	kind           string
+	incident       incidentProvider
+	ok             testparser.Evaluatable
+	sendOn         map[State]bool
 }
 
 func (a *Alerter) Check() error {
-	err := a.Request.Check()
+	webhooks := map[string]*Webhook{
+		"slack":   a.Slack,
+		"teams":   a.Teams,
+		"discord": a.Discord,
+	}
+	templates := map[string]string{
+		"slack":   slackTemplate,
+		"teams":   teamsTemplate,
+		"discord": discordTemplate,
+	}
+
+	a.kind = ""
+	kinds := 0
+	if a.Request != nil {
+		a.kind = "request"
+		kinds++
+	}
+	for k, w := range webhooks {
+		if w == nil {
+			continue
+		}
+		req, err := w.request(templates[k])
+		if err != nil {
+			return err
+		}
+		a.kind = k
+		a.Request = req
+		kinds++
+	}
+	if a.Email != nil {
+		a.kind = "email"
+		kinds++
+	}
+	if a.Exec != nil {
+		a.kind = "exec"
+		kinds++
+	}
+	if a.PagerDuty != nil {
+		a.kind = "pagerduty"
+		a.incident = a.PagerDuty
+		kinds++
+	}
+	if a.Opsgenie != nil {
+		a.kind = "opsgenie"
+		a.incident = a.Opsgenie
+		kinds++
+	}
+	if kinds > 1 {
+		return fmt.Errorf("Alerter can only have one of request, slack, teams, discord, email, exec, pagerduty or opsgenie")
+	}
+
+	var err error
+	switch {
+	case a.kind == "":
+		err = fmt.Errorf("Alerter.Request is required")
+	case a.Email != nil:
+		err = a.Email.Check()
+	case a.Exec != nil:
+		err = a.Exec.Check()
+	case a.incident != nil:
+		err = a.incident.Check()
+	default:
+		err = a.Request.Check()
+	}
+	if err != nil {
+		return err
+	}
+
+	if a.Ok != nil {
+		if a.Email != nil || a.Exec != nil {
+			return fmt.Errorf("Alerter.Ok can only be used with HTTP alerters")
+		}
+		tree, err := testparser.Parse("parser", []byte(*a.Ok))
+		if err != nil {
+			return fmt.Errorf("Alerter.Ok Error: %w", err)
+		}
+		iface, ok := tree.([]interface{})
+		if !ok {
+			return fmt.Errorf("Alerter.Ok Error: %w", err)
+		}
+		a.ok = iface[0].(testparser.Evaluatable)
+	}
+	if a.Response == nil {
+		a.Response = &Response{}
+	}
+	err = a.Response.Check()
 	if err != nil {
 		return err
 	}
@@ -35,51 +185,395 @@ func (a *Alerter) Check() error {
 		a.AlwaysSend = &alwaysSend
 	}
 
+	if a.RepeatInterval != nil && *a.RepeatInterval <= 0 {
+		return fmt.Errorf("Alerter.RepeatInterval must be positive")
+	}
+
+	if a.SendOn != nil {
+		a.sendOn = make(map[State]bool, len(a.SendOn))
+		for _, n := range a.SendOn {
+			state, err := parseState(n)
+			if err != nil {
+				return fmt.Errorf("Alerter.SendOn Error: %w", err)
+			}
+			a.sendOn[state] = true
+		}
+	}
+
+	if a.Retry == nil {
+		a.Retry = &Retry{}
+	}
+	err = a.Retry.Check()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// succeeded returns an error unless the response means the alert was sent.
+// Without an ok statement a request alerter needs a 200, while the other kinds
+// may respond with any 2xx status code, such as Discord's 204.
+func (a *Alerter) succeeded(ctx context.Context, resp *http.Response) error {
+	if a.ok == nil {
+		ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+		if a.kind == "request" {
+			ok = resp.StatusCode == 200
+		}
+		if !ok {
+			return fmt.Errorf("Unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+
+	res, err := a.Response.Run(ctx, resp)
+	if err != nil {
+		return err
+	}
+	ok, err := a.ok.Evaluate(res)
+	if !ok {
+		if err == nil {
+			err = fmt.Errorf("Alerter.Ok failed")
+		}
+		return err
+	}
+	return nil
+}
+
+// sends returns whether the alerter sends alerts for a state, limited by
+// send_on. Incidents are only triggered when a job is Alerting and resolved
+// when it is Ok.
+func (a *Alerter) sends(state State) bool {
+	if a.sendOn != nil && !a.sendOn[state] {
+		return false
+	}
+	if a.incident != nil {
+		return state == AlertingState || state == OkState
+	}
+	return true
+}
+
+// jobAlertState is what an alerter knows about a job
+type jobAlertState struct {
+	state     State
+	sent      time.Time
+	triggered bool
+}
+
+// repeats returns whether the alert should be sent again to remind that the
+// job is still Alerting. Pending and No_Data are only sent when they change.
+func (a *Alerter) repeats(prev jobAlertState, alert Alert) bool {
+	if a.RepeatInterval == nil || alert.State != AlertingState || prev.sent.IsZero() {
+		return false
+	}
+	return time.Since(prev.sent) >= *a.RepeatInterval
+}
+
+// update returns what the alerter knows about the job after the alert, and
+// whether the alert should be sent. An incident is only resolved once it has
+// been triggered, so that starting isup or reloading the config doesn't
+// resolve an incident for every job that is Ok.
+func (a *Alerter) update(prev jobAlertState, exist bool, alert *Alert) (jobAlertState, bool) {
+	changed := !exist || prev.state != alert.State
+	alert.Repeat = !changed && a.repeats(prev, *alert)
+	next := prev
+	if changed {
+		next = jobAlertState{state: alert.State, triggered: prev.triggered}
+	}
+
+	if !changed && !alert.Repeat && !*a.AlwaysSend {
+		return next, false
+	}
+	if !a.sends(alert.State) {
+		return next, false
+	}
+	if a.incident != nil {
+		if alert.State == OkState && !next.triggered {
+			return next, false
+		}
+		next.triggered = alert.State == AlertingState
+	}
+	next.sent = time.Now()
+	return next, true
+}
+
+// maxQueued is the most alerts an alerter holds for a job while they are being
+// retried
+const maxQueued = 1000
+
+// Run sends the alerts that the alerter should until the context is done, then
+// waits for the alerts being sent to stop. Each job's alerts are sent in order
+// by their own queue so that retrying one job's alert doesn't hold up the
+// alerts of the others.
 func (a *Alerter) Run(name string, ctx context.Context, alerts chan Alert) {
-	state := make(map[string]State, 0)
+	state := make(map[string]jobAlertState, 0)
+	outbox, _ := ctx.Value("outbox").(*Outbox)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	queues := make(map[string]chan *queuedAlert)
+	queue := func(job string) chan *queuedAlert {
+		q, ok := queues[job]
+		if !ok {
+			q = make(chan *queuedAlert, maxQueued)
+			queues[job] = q
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.deliverQueue(name, ctx, outbox, q)
+			}()
+		}
+		return q
+	}
+
+	// Send anything left over from before a restart first
+	pending, err := outbox.pending(name)
+	if err != nil {
+		log.Error().
+			Str("alerter", name).
+			Err(err).
+			Msg("Could not read the outbox")
+	}
+	for _, q := range pending {
+		a.enqueue(name, outbox, queue(q.Alert.Job), q)
+	}
 
 	for {
 		select {
 		case alert := <-alerts:
-			prevState, exist := state[alert.Job]
-			if !exist || prevState != alert.State || *a.AlwaysSend {
-				state[alert.Job] = alert.State
-
-				repl := Replacement{}
-				repl = repl.WithEnv()
-				repl["job"] = alert.Job
-				repl["state"] = alert.State.String()
-				for k, v := range alert.Values {
-					repl[k] = v
-				}
+			prev, exist := state[alert.Job]
+			next, send := a.update(prev, exist, &alert)
+			state[alert.Job] = next
 
-				resp, err := a.Request.Run(ctx, &repl)
+			if send {
+				q := &queuedAlert{
+					Queued: time.Now(),
+					Alert:  alert,
+				}
+				err := outbox.add(name, q)
 				if err != nil {
-					log.Warn().
+					log.Error().
 						Str("alerter", name).
 						Str("job", alert.Job).
 						Err(err).
-						Msg("Alert errorer")
-
-				} else if resp.StatusCode < 200 || resp.StatusCode > 200 {
-					log.Warn().
-						Str("alerter", name).
-						Str("job", alert.Job).
-						Int("status_code", resp.StatusCode).
-						Msg("Alert failed to send")
-				} else {
-					log.Info().
-						Str("alerter", name).
-						Str("job", alert.Job).
-						Int("status_code", resp.StatusCode).
-						Msg("Alert sent")
+						Msg("Could not write alert to the outbox")
 				}
+				a.enqueue(name, outbox, queue(alert.Job), q)
 			}
 		case <-ctx.Done():
 			return
 		}
 	}
 }
+
+// enqueue queues the alert to be sent, dropping it if the queue is full
+func (a *Alerter) enqueue(name string, outbox *Outbox, queue chan *queuedAlert, q *queuedAlert) {
+	select {
+	case queue <- q:
+	default:
+		a.drop(name, outbox, q, fmt.Errorf("Alert queue is full"))
+	}
+}
+
+// deliverQueue sends a job's queued alerts in order, retrying each one until it
+// is sent or runs out of attempts. Alerts left when the context is done stay in
+// the outbox.
+func (a *Alerter) deliverQueue(name string, ctx context.Context, outbox *Outbox, queue chan *queuedAlert) {
+	for {
+		var q *queuedAlert
+		select {
+		case q = <-queue:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			q.Attempts++
+			statusCode, err := a.send(name, ctx, q.Alert)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err := outbox.remove(name, q)
+				if err != nil {
+					log.Error().
+						Str("alerter", name).
+						Str("job", q.Alert.Job).
+						Err(err).
+						Msg("Could not remove alert from the outbox")
+				}
+				break
+			}
+			if q.Attempts >= *a.Retry.Attempts || !retryable(statusCode) {
+				a.drop(name, outbox, q, err)
+				break
+			}
+
+			delay := a.Retry.delay(q.Attempts)
+			log.Info().
+				Str("alerter", name).
+				Str("job", q.Alert.Job).
+				Int("attempts", q.Attempts).
+				Dur("retry_in", delay).
+				Msg("Retrying alert")
+			err = outbox.add(name, q)
+			if err != nil {
+				log.Error().
+					Str("alerter", name).
+					Str("job", q.Alert.Job).
+					Err(err).
+					Msg("Could not write alert to the outbox")
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// drop gives up on an alert, writing it to the dead letter log
+func (a *Alerter) drop(name string, outbox *Outbox, q *queuedAlert, reason error) {
+	log.Error().
+		Str("alerter", name).
+		Str("job", q.Alert.Job).
+		Str("state", q.Alert.State.String()).
+		Int("attempts", q.Attempts).
+		Err(reason).
+		Msg("Alert dropped")
+	alertsDropped.WithLabelValues(name, q.Alert.Job).Inc()
+
+	err := outbox.deadLetter(name, q, reason)
+	if err != nil {
+		log.Error().
+			Str("alerter", name).
+			Str("job", q.Alert.Job).
+			Err(err).
+			Msg("Could not write alert to the dead letter log")
+	}
+	err = outbox.remove(name, q)
+	if err != nil {
+		log.Error().
+			Str("alerter", name).
+			Str("job", q.Alert.Job).
+			Err(err).
+			Msg("Could not remove alert from the outbox")
+	}
+}
+
+// fields returns the details of the alert, without the job's values
+func (a Alert) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"job":    a.Job,
+		"state":  a.State.String(),
+		"repeat": a.Repeat,
+		"labels": a.Labels,
+		"tests":  a.testsReplacement(),
+	}
+	if t := a.Transition; t != nil {
+		fields["from_state"] = t.From.String()
+		fields["since"] = t.Time.Format(time.RFC3339)
+		fields["expression"] = t.Expression
+		fields["failed"] = t.Failed
+		fields["failed_tests"] = strings.Join(t.Failed, ", ")
+		fields["reason"] = t.Reason()
+	}
+	return fields
+}
+
+// replacement returns the variables available to an alerter's templates
+func (a Alert) replacement() Replacement {
+	repl := Replacement{}
+	repl = repl.WithEnv()
+	for k, v := range a.fields() {
+		repl[k] = v
+	}
+	for k, v := range a.Values {
+		repl[k] = v
+	}
+	return repl
+}
+
+// send sends the alert, logging and publishing the outcome. The status code of
+// the response is returned for HTTP alerters.
+func (a *Alerter) send(name string, ctx context.Context, alert Alert) (int, error) {
+	repl := alert.replacement()
+
+	ctx, span := tracer().Start(ctx, "isup.alert", trace.WithAttributes(
+		attribute.String("isup.alerter", name),
+		attribute.String("isup.job", alert.Job),
+		attribute.String("isup.state", alert.State.String()),
+	))
+	defer span.End()
+
+	statusCode, err := a.deliver(ctx, alert, &repl)
+	if err != nil && statusCode != 0 {
+		log.Warn().
+			Str("alerter", name).
+			Str("job", alert.Job).
+			Int("status_code", statusCode).
+			Err(err).
+			Msg("Alert failed to send")
+	} else if err != nil {
+		log.Warn().
+			Str("alerter", name).
+			Str("job", alert.Job).
+			Err(err).
+			Msg("Alert errorer")
+	} else {
+		ev := log.Info().
+			Str("alerter", name).
+			Str("job", alert.Job)
+		if statusCode != 0 {
+			ev = ev.Int("status_code", statusCode)
+		}
+		ev.Msg("Alert sent")
+	}
+
+	if err != nil {
+		alertsFailed.WithLabelValues(name, alert.Job).Inc()
+		span.SetStatus(codes.Error, redact.String(err.Error()))
+	} else {
+		alertsSent.WithLabelValues(name, alert.Job).Inc()
+	}
+
+	for _, l := range listeners(ctx) {
+		if al, ok := l.(AlertListener); ok {
+			al.AlertSent(name, alert, err)
+		}
+	}
+
+	return statusCode, err
+}
+
+// deliver sends the alert using the alerter's kind, returning the status code
+// of the response for HTTP alerters
+func (a *Alerter) deliver(ctx context.Context, alert Alert, repl *Replacement) (int, error) {
+	if a.Email != nil {
+		return 0, a.Email.Send(ctx, repl)
+	}
+	if a.Exec != nil {
+		return 0, a.Exec.Send(ctx, alert, repl)
+	}
+
+	req := a.Request
+	if a.incident != nil {
+		var err error
+		req, err = a.incident.request(ctx, alert, repl)
+		if err != nil {
+			return 0, err
+		}
+		// The request is already rendered
+		repl = nil
+	}
+
+	resp, err := req.Run(ctx, repl)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, a.succeeded(ctx, resp)
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlerterUpdateIncident(t *testing.T) {
+	key := "key"
+	a := &Alerter{
+		PagerDuty: &PagerDuty{RoutingKey: &key},
+	}
+	err := a.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		state State
+		send  bool
+	}{
+		// A job that is Ok when isup starts has no incident to resolve
+		{OkState, false},
+		{OkState, false},
+		{PendingState, false},
+		{AlertingState, true},
+		{NoDataState, false},
+		{OkState, true},
+		{OkState, false},
+	}
+
+	var prev jobAlertState
+	exist := false
+	for i, s := range steps {
+		alert := Alert{Job: "web", State: s.state}
+		next, send := a.update(prev, exist, &alert)
+		if send != s.send {
+			t.Errorf("step %d: %s sent = %t, want %t", i, s.state, send, s.send)
+		}
+		prev, exist = next, true
+	}
+}
+
+func TestAlerterUpdateRequest(t *testing.T) {
+	url := "http://localhost/"
+	a := &Alerter{
+		Request: &Request{URL: &url},
+	}
+	err := a.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alert := Alert{Job: "web", State: OkState}
+	prev, send := a.update(jobAlertState{}, false, &alert)
+	if !send {
+		t.Errorf("first Ok sent = false, want true")
+	}
+	_, send = a.update(prev, true, &alert)
+	if send {
+		t.Errorf("unchanged Ok sent = true, want false")
+	}
+}
+
+func TestAlerterRepeatsAlerting(t *testing.T) {
+	url := "http://localhost/"
+	interval := time.Minute
+	a := &Alerter{
+		Request:        &Request{URL: &url},
+		RepeatInterval: &interval,
+	}
+	err := a.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []State{OkState, PendingState, AlertingState, NoDataState} {
+		prev := jobAlertState{state: s, sent: time.Now().Add(-time.Hour)}
+		alert := Alert{Job: "web", State: s}
+		_, send := a.update(prev, true, &alert)
+		if want := s == AlertingState; send != want || alert.Repeat != want {
+			t.Errorf("%s repeated = %t, want %t", s, send, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Webhook configures a chat alerter that posts a message to an incoming
+// webhook URL
+type Webhook struct {
+	URL      *string
+	Template *string
+}
+
+var stateColors = map[string]string{
+	OkState.String():       "#2eb886",
+	PendingState.String():  "#daa038",
+	AlertingState.String(): "#a30200",
+	NoDataState.String():   "#808080",
+}
+
+// stateColor returns the colour used for a state in chat messages
+func stateColor(state interface{}) string {
+	switch v := state.(type) {
+	case State:
+		return stateColors[v.String()]
+	case string:
+		if color, ok := stateColors[v]; ok {
+			return color
+		}
+	}
+	return stateColors[NoDataState.String()]
+}
+
+// stateColorDecimal returns the colour used for a state as a number
+func stateColorDecimal(state interface{}) int64 {
+	color, _ := strconv.ParseInt(strings.TrimPrefix(stateColor(state), "#"), 16, 32)
+	return color
+}
+
+const slackTemplate = `{
+  "text": {{printf "%s is %s" .job .state | toJson}},
+  "attachments": [
+    {
+      "color": "{{stateColor .state}}",
+      "title": {{printf "%s is %s" .job .state | toJson}},
+      "text": {{.reason | default "" | toJson}},
+      "fields": [{{range $i, $t := .tests}}{{if $i}},{{end}}
+        {
+          "title": {{toJson $t.name}},
+          "value": {{printf "%s (%d) in %s %s" $t.state $t.status_code $t.duration $t.error | trim | toJson}},
+          "short": false
+        }{{end}}
+      ],
+      "ts": {{now.Unix}}
+    }
+  ]
+}`
+
+const teamsTemplate = `{
+  "@type": "MessageCard",
+  "@context": "https://schema.org/extensions",
+  "themeColor": "{{stateColor .state | replace "#" ""}}",
+  "summary": {{printf "%s is %s" .job .state | toJson}},
+  "title": {{printf "%s is %s" .job .state | toJson}},
+  "text": {{.reason | default " " | toJson}},
+  "sections": [
+    {
+      "facts": [{{range $i, $t := .tests}}{{if $i}},{{end}}
+        {
+          "name": {{toJson $t.name}},
+          "value": {{printf "%s (%d) in %s %s" $t.state $t.status_code $t.duration $t.error | trim | toJson}}
+        }{{end}}
+      ]
+    }
+  ]
+}`
+
+const discordTemplate = `{
+  "embeds": [
+    {
+      "title": {{printf "%s is %s" .job .state | toJson}},
+      "description": {{.reason | default "" | toJson}},
+      "color": {{stateColorDecimal .state}},
+      "fields": [{{range $i, $t := .tests}}{{if $i}},{{end}}
+        {
+          "name": {{toJson $t.name}},
+          "value": {{printf "%s (%d) in %s %s" $t.state $t.status_code $t.duration $t.error | trim | toJson}},
+          "inline": false
+        }{{end}}
+      ],
+      "timestamp": "{{now.UTC.Format "2006-01-02T15:04:05Z07:00"}}"
+    }
+  ]
+}`
+
+// request creates the Request used to post to the webhook, using the default
+// template unless it is overridden
+func (w *Webhook) request(defaultTemplate string) (*Request, error) {
+	if w.URL == nil {
+		return nil, fmt.Errorf("Webhook.URL cannot be empty")
+	}
+
+	body := defaultTemplate
+	if w.Template != nil {
+		body = *w.Template
+	}
+	_, err := template.New("body").Funcs(templateFuncs(context.Background())).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("Webhook.Template Error: %w", err)
+	}
+
+	method := "POST"
+	return &Request{
+		Method: &method,
+		URL:    w.URL,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: &body,
+	}, nil
+}
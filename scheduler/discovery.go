@@ -0,0 +1,14 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Discoverer finds the Targets that a Job should be expanded with while the
+// schedule is running.
+type Discoverer interface {
+	Check() error
+	Discover(ctx context.Context) ([]Target, error)
+	Interval() time.Duration
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DNSSD discovers Targets by resolving DNS SRV records
+type DNSSD struct {
+	Names   []string
+	Refresh *time.Duration `yaml:"refresh_interval"`
+	last    map[string][]Target
+}
+
+func (d *DNSSD) Check() error {
+	if len(d.Names) == 0 {
+		return fmt.Errorf("DNSSD.Names cannot be empty")
+	}
+
+	if d.Refresh == nil {
+		refresh := 30 * time.Second
+		d.Refresh = &refresh
+	}
+
+	return nil
+}
+
+func (d *DNSSD) Interval() time.Duration {
+	return *d.Refresh
+}
+
+// Discover resolves each name on its own. A name that doesn't exist has no
+// Targets, and a name that can't be resolved keeps the Targets it last had so
+// that its jobs keep running without hiding changes to the other names.
+func (d *DNSSD) Discover(ctx context.Context) ([]Target, error) {
+	if d.last == nil {
+		d.last = make(map[string][]Target, len(d.Names))
+	}
+
+	targets := make([]Target, 0)
+	for _, name := range d.Names {
+		found, err := lookupSRV(ctx, name)
+		if err != nil {
+			log.Warn().
+				Str("name", name).
+				Err(err).
+				Msg("Could not resolve SRV record")
+			found = d.last[name]
+		}
+		d.last[name] = found
+		targets = append(targets, found...)
+	}
+
+	return targets, nil
+}
+
+// lookupSRV returns a Target for each SRV record of name, or none if it
+// doesn't exist
+func lookupSRV(ctx context.Context, name string) ([]Target, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(records))
+	for _, r := range records {
+		host := strings.TrimSuffix(r.Target, ".")
+		port := strconv.Itoa(int(r.Port))
+		targets = append(targets, Target{
+			"target": net.JoinHostPort(host, port),
+			"host":   host,
+			"port":   port,
+		})
+	}
+	return targets, nil
+}
@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Email configures an alerter that sends a message through an SMTP server
+type Email struct {
+	Host     *string
+	Port     *int
+	TLS      *string
+	Username *string
+	Password *string
+	From     *string
+	To       []string
+	Subject  *string
+	Body     *string
+}
+
+const emailSubject = `[isup] {{.job}} is {{.state}}`
+
+const emailBody = `{{.job}} is {{.state}}{{with .reason}}
+
+{{.}}{{end}}
+{{range .tests}}
+{{.name}}: {{.state}} ({{.status_code}}) in {{.duration}}{{with .error}} {{.}}{{end}}{{end}}
+`
+
+// emailTimeout is used when sending an email if the context has no deadline
+const emailTimeout = 30 * time.Second
+
+func (e *Email) Check() error {
+	if e.Host == nil {
+		return fmt.Errorf("Email.Host cannot be empty")
+	}
+
+	if e.TLS == nil {
+		mode := "starttls"
+		e.TLS = &mode
+	} else {
+		mode := strings.ToLower(*e.TLS)
+		e.TLS = &mode
+	}
+	switch *e.TLS {
+	case "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("Email.TLS '%s' isn't valid", *e.TLS)
+	}
+
+	if e.Port == nil {
+		port := 587
+		if *e.TLS == "tls" {
+			port = 465
+		}
+		e.Port = &port
+	}
+
+	if e.Username != nil && e.Password == nil {
+		return fmt.Errorf("Email.Password is required with Email.Username")
+	}
+
+	if e.From == nil {
+		return fmt.Errorf("Email.From cannot be empty")
+	}
+	_, err := mail.ParseAddress(*e.From)
+	if err != nil {
+		return fmt.Errorf("Email.From Error: %w", err)
+	}
+	if len(e.To) == 0 {
+		return fmt.Errorf("Email.To cannot be empty")
+	}
+	for _, to := range e.To {
+		_, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("Email.To Error: %w", err)
+		}
+	}
+
+	if e.Subject == nil {
+		subject := emailSubject
+		e.Subject = &subject
+	}
+	if e.Body == nil {
+		body := emailBody
+		e.Body = &body
+	}
+	templates := map[string]*string{
+		"Subject":  e.Subject,
+		"Body":     e.Body,
+		"Password": e.Password,
+	}
+	for n, t := range templates {
+		if t == nil {
+			continue
+		}
+		_, err := template.New(n).Funcs(templateFuncs(context.Background())).Parse(*t)
+		if err != nil {
+			return fmt.Errorf("Email.%s Error: %w", n, err)
+		}
+	}
+
+	return nil
+}
+
+// message renders the email, including its headers, ready to be sent
+func (e *Email) message(ctx context.Context, repl *Replacement) ([]byte, error) {
+	subject, err := repl.Render(ctx, "subject", *e.Subject)
+	if err != nil {
+		return nil, err
+	}
+	body, err := repl.Render(ctx, "body", *e.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", *e.From},
+		{"To", strings.Join(e.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject))},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return msg.Bytes(), nil
+}
+
+// Send renders the email and delivers it to the SMTP server
+func (e *Email) Send(ctx context.Context, repl *Replacement) error {
+	msg, err := e.message(ctx, repl)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(emailTimeout)
+	}
+
+	addr := net.JoinHostPort(*e.Host, strconv.Itoa(*e.Port))
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	tlsConfig := &tls.Config{ServerName: *e.Host}
+	if *e.TLS == "tls" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, *e.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *e.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		err = c.StartTLS(tlsConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.Username != nil {
+		password, err := repl.Render(ctx, "password", *e.Password)
+		if err != nil {
+			return err
+		}
+		err = c.Auth(smtp.PlainAuth("", *e.Username, password, *e.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(*e.From)
+	err = c.Mail(from.Address)
+	if err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		rcpt, _ := mail.ParseAddress(to)
+		err = c.Rcpt(rcpt.Address)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
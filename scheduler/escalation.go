@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Escalation sends a job's alerts to more alerters the longer it is Alerting.
+// It is used in a job's alerters in the same way as an Alerter.
+type Escalation struct {
+	Steps []*EscalationStep
+}
+
+// EscalationStep lists the alerters that are sent a job's alerts once it has
+// been Alerting for After
+type EscalationStep struct {
+	After    *time.Duration
+	Alerters []string
+}
+
+func (e *Escalation) Check(validAlerters []string) error {
+	if len(e.Steps) == 0 {
+		return fmt.Errorf("Escalation.Steps cannot be empty")
+	}
+
+	var last time.Duration
+	for i, s := range e.Steps {
+		if s.After == nil {
+			after := time.Duration(0)
+			s.After = &after
+		}
+		if *s.After < last {
+			return fmt.Errorf("Escalation.Steps[%d] cannot be after the next step", i-1)
+		}
+		last = *s.After
+
+		if len(s.Alerters) == 0 {
+			return fmt.Errorf("Escalation.Steps[%d].Alerters cannot be empty", i)
+		}
+		err := checkAlerters(s.Alerters, validAlerters)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reached returns how many steps have been reached after being Alerting for
+// elapsed
+func (e *Escalation) reached(elapsed time.Duration) int {
+	n := 0
+	for _, s := range e.Steps {
+		if *s.After > elapsed {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// escalationState is the progress of a job through an Escalation
+type escalationState struct {
+	alert   Alert
+	start   time.Time
+	reached int
+}
+
+// escalationCheck is how often the Router checks whether a job has reached the
+// next step of an Escalation
+const escalationCheck = 5 * time.Second
+
+// escalate routes an alert through the escalation. Steps that are reached
+// immediately are sent every alert. Once the job is Alerting the later steps
+// are sent its alerts as they are reached, until it is Ok again and they are
+// sent the alert that resolves it. A job that goes from Alerting to Pending or
+// No_Data keeps escalating, so that flapping doesn't restart the clock. A
+// silenced alert is tracked in the same way but isn't sent.
+func (r *Router) escalate(name string, alert Alert, silenced bool, chans map[string]chan Alert, states map[string]*escalationState) {
+	e := r.Escalations[name]
+	st := states[alert.Job]
+	send := func(start, end int) {
+		if !silenced {
+			r.sendSteps(e, alert, start, end, chans)
+		}
+	}
+
+	if alert.State == OkState {
+		reached := e.reached(0)
+		if st != nil {
+			reached = st.reached
+			delete(states, alert.Job)
+		}
+		send(0, reached)
+		return
+	}
+
+	if st == nil && alert.State != AlertingState {
+		send(0, e.reached(0))
+		return
+	}
+
+	if st == nil {
+		st = &escalationState{
+			start: time.Now(),
+		}
+		if t := alert.Transition; t != nil && t.To == AlertingState {
+			st.start = t.Time
+		}
+		states[alert.Job] = st
+	}
+	st.alert = alert
+	send(0, st.reached)
+	if !silenced {
+		r.advance(name, st, chans)
+	}
+}
+
+// advance sends the latest alert to the steps the job has reached since it
+// was last checked
+func (r *Router) advance(name string, st *escalationState, chans map[string]chan Alert) {
+	e := r.Escalations[name]
+	reached := e.reached(time.Since(st.start))
+	if reached <= st.reached {
+		return
+	}
+
+	if st.reached > 0 {
+		log.Info().
+			Str("escalation", name).
+			Str("job", st.alert.Job).
+			Int("step", reached).
+			Msg("Alert escalated")
+	}
+	r.sendSteps(e, st.alert, st.reached, reached, chans)
+	st.reached = reached
+}
+
+// sendSteps sends the alert to the alerters of the steps from start up to end
+func (r *Router) sendSteps(e *Escalation, alert Alert, start, end int, chans map[string]chan Alert) {
+	for _, s := range e.Steps[start:end] {
+		for _, a := range s.Alerters {
+			chans[a] <- alert
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscalateFlapping(t *testing.T) {
+	now, later := time.Duration(0), time.Hour
+	e := &Escalation{
+		Steps: []*EscalationStep{
+			{After: &now, Alerters: []string{"chat"}},
+			{After: &later, Alerters: []string{"pager"}},
+		},
+	}
+	err := e.Check([]string{"chat", "pager"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRouter()
+	r.Escalations = map[string]*Escalation{"oncall": e}
+	chans := map[string]chan Alert{
+		"chat":  make(chan Alert, 10),
+		"pager": make(chan Alert, 10),
+	}
+	states := make(map[string]*escalationState)
+
+	r.escalate("oncall", Alert{Job: "web", State: AlertingState}, false, chans, states)
+	start := states["web"].start
+
+	for _, s := range []State{NoDataState, PendingState, AlertingState} {
+		r.escalate("oncall", Alert{Job: "web", State: s}, false, chans, states)
+		st, ok := states["web"]
+		if !ok {
+			t.Fatalf("escalation was reset by %s", s)
+		}
+		if !st.start.Equal(start) {
+			t.Errorf("escalation restarted by %s", s)
+		}
+	}
+
+	r.escalate("oncall", Alert{Job: "web", State: OkState}, false, chans, states)
+	if _, ok := states["web"]; ok {
+		t.Errorf("escalation wasn't reset by Ok")
+	}
+
+	if len(chans["chat"]) != 5 {
+		t.Errorf("chat was sent %d alerts, want 5", len(chans["chat"]))
+	}
+	if len(chans["pager"]) != 0 {
+		t.Errorf("pager was sent %d alerts, want 0", len(chans["pager"]))
+	}
+}
+
+func TestEscalateSilenced(t *testing.T) {
+	now := time.Duration(0)
+	e := &Escalation{
+		Steps: []*EscalationStep{
+			{After: &now, Alerters: []string{"pager"}},
+		},
+	}
+	err := e.Check([]string{"pager"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRouter()
+	r.Escalations = map[string]*Escalation{"oncall": e}
+	chans := map[string]chan Alert{
+		"pager": make(chan Alert, 10),
+	}
+	states := make(map[string]*escalationState)
+
+	r.escalate("oncall", Alert{Job: "web", State: AlertingState}, false, chans, states)
+	r.escalate("oncall", Alert{Job: "web", State: OkState}, true, chans, states)
+	if _, ok := states["web"]; ok {
+		t.Errorf("escalation wasn't reset by a silenced Ok")
+	}
+	r.escalate("oncall", Alert{Job: "web", State: AlertingState}, true, chans, states)
+	if _, ok := states["web"]; !ok {
+		t.Errorf("escalation wasn't started by a silenced Alerting")
+	}
+
+	if len(chans["pager"]) != 1 {
+		t.Errorf("pager was sent %d alerts, want 1", len(chans["pager"]))
+	}
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Exec configures an alerter that runs a local command
+type Exec struct {
+	Command []string
+	Timeout *time.Duration
+	Dir     *string
+}
+
+// execOutputLimit is the most output of a command that is kept, from the end
+const execOutputLimit = 4096
+
+// execOutputWait is how long to wait for the rest of the output once the
+// command has exited, as processes it started in the background may still
+// hold its output open
+const execOutputWait = time.Second
+
+func (e *Exec) Check() error {
+	if len(e.Command) == 0 {
+		return fmt.Errorf("Exec.Command cannot be empty")
+	}
+	for i, arg := range e.Command {
+		_, err := template.New("command").Funcs(templateFuncs(context.Background())).Parse(arg)
+		if err != nil {
+			return fmt.Errorf("Exec.Command[%d] Error: %w", i, err)
+		}
+	}
+
+	if e.Timeout == nil {
+		timeout := 30 * time.Second
+		e.Timeout = &timeout
+	}
+	if *e.Timeout <= 0 {
+		return fmt.Errorf("Exec.Timeout must be positive")
+	}
+
+	return nil
+}
+
+// execEnv returns the alert as ISUP_ environment variables, with each of the
+// job's values as ISUP_VALUE_<NAME> and labels as ISUP_LABEL_<NAME>
+func execEnv(alert Alert) []string {
+	env := []string{}
+	for k, v := range alert.fields() {
+		switch val := v.(type) {
+		case string:
+			env = append(env, fmt.Sprintf("ISUP_%s=%s", envName(k), val))
+		case bool:
+			env = append(env, fmt.Sprintf("ISUP_%s=%t", envName(k), val))
+		}
+	}
+	for k, v := range alert.Values {
+		env = append(env, fmt.Sprintf("ISUP_VALUE_%s=%s", envName(k), v))
+	}
+	for k, v := range alert.Labels {
+		env = append(env, fmt.Sprintf("ISUP_LABEL_%s=%s", envName(k), v))
+	}
+	return env
+}
+
+// envName converts a variable name into an environment variable name
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, name)
+}
+
+// Send runs the command with the alert in its environment and as JSON on its
+// stdin
+func (e *Exec) Send(ctx context.Context, alert Alert, repl *Replacement) error {
+	args := make([]string, len(e.Command))
+	for i, arg := range e.Command {
+		a, err := repl.Render(ctx, "command", arg)
+		if err != nil {
+			return err
+		}
+		args[i] = a
+	}
+
+	input := alert.fields()
+	input["values"] = alert.Values
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *e.Timeout)
+	defer cancel()
+
+	// The output is read through a pipe rather than a buffer so that waiting
+	// for the command doesn't also wait for any processes it started in the
+	// background, which would ignore the timeout
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), execEnv(alert)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if e.Dir != nil {
+		cmd.Dir = *e.Dir
+	}
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	output := &tailWriter{limit: execOutputLimit}
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		_, _ = io.Copy(output, r)
+	}()
+
+	err = cmd.Wait()
+	select {
+	case <-copied:
+	case <-time.After(execOutputWait):
+		r.Close()
+		<-copied
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("Command timed out after %s", *e.Timeout)
+	}
+	if err != nil {
+		out := strings.TrimSpace(output.String())
+		if out == "" {
+			return err
+		}
+		return fmt.Errorf("%w, output: %s", err, out)
+	}
+
+	log.Debug().
+		Str("job", alert.Job).
+		Str("command", args[0]).
+		Str("output", output.String()).
+		Msg("Alert command finished")
+
+	return nil
+}
+
+// tailWriter keeps the last limit bytes written to it
+type tailWriter struct {
+	buf   []byte
+	limit int
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.limit {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.limit:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailWriter) String() string {
+	return string(t.buf)
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecBackgroundTimeout(t *testing.T) {
+	timeout := 500 * time.Millisecond
+	e := &Exec{
+		Command: []string{"sh", "-c", "sleep 30 & echo started; sleep 30"},
+		Timeout: &timeout,
+	}
+	err := e.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alert := Alert{Job: "web", State: AlertingState}
+	repl := alert.replacement()
+	start := time.Now()
+	err = e.Send(context.Background(), alert, &repl)
+	elapsed := time.Since(start)
+
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Send() error = %v, want a timeout", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "started") {
+		t.Errorf("Send() error = %v, want the output", err)
+	}
+	if limit := timeout + execOutputWait + time.Second; elapsed > limit {
+		t.Errorf("Send() took %s, want less than %s", elapsed, limit)
+	}
+}
+
+func TestTailWriter(t *testing.T) {
+	w := &tailWriter{limit: 4}
+	w.Write([]byte("abc"))
+	w.Write([]byte("defg"))
+	if got := w.String(); got != "defg" {
+		t.Errorf("String() = %q, want %q", got, "defg")
+	}
+}
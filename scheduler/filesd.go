@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileSD discovers Targets from JSON or YAML files in the Prometheus file_sd
+// format.
+type FileSD struct {
+	Files   []string
+	Refresh *time.Duration `yaml:"refresh_interval"`
+}
+
+type fileSDGroup struct {
+	Targets []string
+	Labels  map[string]string
+}
+
+func (f *FileSD) Check() error {
+	if len(f.Files) == 0 {
+		return fmt.Errorf("FileSD.Files cannot be empty")
+	}
+	for _, p := range f.Files {
+		_, err := filepath.Match(p, "")
+		if err != nil {
+			return fmt.Errorf("FileSD.Files '%s' Error: %w", p, err)
+		}
+	}
+
+	if f.Refresh == nil {
+		refresh := 30 * time.Second
+		f.Refresh = &refresh
+	}
+
+	return nil
+}
+
+func (f *FileSD) Interval() time.Duration {
+	return *f.Refresh
+}
+
+func (f *FileSD) Discover(ctx context.Context) ([]Target, error) {
+	targets := make([]Target, 0)
+
+	for _, p := range f.Files {
+		files, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			// JSON is valid YAML so both formats can be read the same way
+			var groups []fileSDGroup
+			err = yaml.Unmarshal(data, &groups)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' Error: %w", file, err)
+			}
+
+			for _, g := range groups {
+				for _, address := range g.Targets {
+					target := make(Target, len(g.Labels)+1)
+					for k, v := range g.Labels {
+						target[k] = v
+					}
+					target["target"] = address
+					targets = append(targets, target)
+				}
+			}
+		}
+	}
+
+	return targets, nil
+}
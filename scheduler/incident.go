@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// incidentProvider maps isup's states onto an incident management service,
+// triggering an incident when a job is Alerting and resolving it once the job
+// is Ok again
+type incidentProvider interface {
+	Check() error
+	request(ctx context.Context, alert Alert, repl *Replacement) (*Request, error)
+}
+
+// incidentKey is the stable key used to deduplicate a job's incidents
+func incidentKey(job string) string {
+	return "isup/" + job
+}
+
+// incidentSummary describes the alert in a single line
+func incidentSummary(alert Alert) string {
+	summary := fmt.Sprintf("%s is %s", alert.Job, alert.State)
+	if alert.Transition != nil {
+		if reason := alert.Transition.Reason(); reason != "" {
+			summary += ": " + reason
+		}
+	}
+	return summary
+}
+
+// incidentDetails returns the job's values and the details of its transition
+func incidentDetails(alert Alert) map[string]string {
+	details := make(map[string]string, len(alert.Values)+4)
+	for k, v := range alert.Values {
+		details[k] = v
+	}
+	if t := alert.Transition; t != nil {
+		details["from_state"] = t.From.String()
+		details["since"] = t.Time.Format(time.RFC3339)
+		details["expression"] = t.Expression
+		details["failed_tests"] = strings.Join(t.Failed, ", ")
+	}
+	return details
+}
+
+// incidentRequest creates a Request that posts body as JSON
+func incidentRequest(url string, headers map[string]string, body interface{}) (*Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	method := "POST"
+	b := string(data)
+	h := map[string]string{
+		"Content-Type": "application/json",
+	}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return &Request{
+		Method:  &method,
+		URL:     &url,
+		Headers: h,
+		Body:    &b,
+	}, nil
+}
+
+// PagerDuty configures an alerter that uses the PagerDuty Events API v2
+type PagerDuty struct {
+	RoutingKey *string `yaml:"routing_key"`
+	Severity   *string
+	Source     *string
+	URL        *string
+}
+
+func (p *PagerDuty) Check() error {
+	if p.RoutingKey == nil {
+		return fmt.Errorf("PagerDuty.RoutingKey cannot be empty")
+	}
+	if p.Severity == nil {
+		severity := "critical"
+		p.Severity = &severity
+	}
+	switch *p.Severity {
+	case "critical", "error", "warning", "info":
+	default:
+		return fmt.Errorf("PagerDuty.Severity '%s' isn't valid", *p.Severity)
+	}
+	if p.Source == nil {
+		source := "isup"
+		p.Source = &source
+	}
+	if p.URL == nil {
+		url := "https://events.pagerduty.com/v2/enqueue"
+		p.URL = &url
+	}
+	return nil
+}
+
+func (p *PagerDuty) request(ctx context.Context, alert Alert, repl *Replacement) (*Request, error) {
+	key, err := repl.Render(ctx, "routing_key", *p.RoutingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	event := map[string]interface{}{
+		"routing_key":  key,
+		"event_action": "resolve",
+		"dedup_key":    incidentKey(alert.Job),
+	}
+	if alert.State == AlertingState {
+		timestamp := time.Now()
+		if alert.Transition != nil {
+			timestamp = alert.Transition.Time
+		}
+		event["event_action"] = "trigger"
+		event["payload"] = map[string]interface{}{
+			"summary":        incidentSummary(alert),
+			"source":         *p.Source,
+			"severity":       *p.Severity,
+			"timestamp":      timestamp.Format(time.RFC3339),
+			"component":      alert.Job,
+			"custom_details": incidentDetails(alert),
+		}
+	}
+
+	return incidentRequest(*p.URL, nil, event)
+}
+
+// Opsgenie configures an alerter that uses the Opsgenie Alert API
+type Opsgenie struct {
+	APIKey   *string `yaml:"api_key"`
+	Priority *string
+	Tags     []string
+	URL      *string
+}
+
+func (o *Opsgenie) Check() error {
+	if o.APIKey == nil {
+		return fmt.Errorf("Opsgenie.APIKey cannot be empty")
+	}
+	if o.Priority == nil {
+		priority := "P3"
+		o.Priority = &priority
+	}
+	switch *o.Priority {
+	case "P1", "P2", "P3", "P4", "P5":
+	default:
+		return fmt.Errorf("Opsgenie.Priority '%s' isn't valid", *o.Priority)
+	}
+	if o.URL == nil {
+		url := "https://api.opsgenie.com"
+		o.URL = &url
+	}
+	return nil
+}
+
+func (o *Opsgenie) request(ctx context.Context, alert Alert, repl *Replacement) (*Request, error) {
+	key, err := repl.Render(ctx, "api_key", *o.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{
+		"Authorization": "GenieKey " + key,
+	}
+	alias := incidentKey(alert.Job)
+	base := strings.TrimRight(*o.URL, "/")
+
+	if alert.State != AlertingState {
+		u := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", base, url.PathEscape(alias))
+		return incidentRequest(u, headers, map[string]interface{}{
+			"source": "isup",
+			"note":   fmt.Sprintf("%s is %s", alert.Job, alert.State),
+		})
+	}
+
+	message := []rune(incidentSummary(alert))
+	if len(message) > 130 {
+		// Opsgenie rejects longer messages, the reason is also in the description
+		message = append(message[:127], []rune("...")...)
+	}
+	description := incidentSummary(alert)
+	for _, t := range alert.testsReplacement() {
+		description += fmt.Sprintf("\n%s: %s (%d) in %s %s", t["name"], t["state"], t["status_code"], t["duration"], t["error"])
+	}
+
+	body := map[string]interface{}{
+		"message":     string(message),
+		"alias":       alias,
+		"description": description,
+		"priority":    *o.Priority,
+		"source":      "isup",
+		"entity":      alert.Job,
+		"details":     incidentDetails(alert),
+	}
+	if len(o.Tags) > 0 {
+		body["tags"] = o.Tags
+	}
+
+	return incidentRequest(base+"/v2/alerts", headers, body)
+}
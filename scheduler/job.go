@@ -7,8 +7,12 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"isup/jobparser"
+	"isup/redact"
 )
 
 type Job struct {
@@ -20,8 +24,17 @@ type Job struct {
 	Tests         map[string]*Test
 	Alerters      []string
 	Values        map[string]string
+	Labels        map[string]string
+	Maintenance   []*MaintenanceWindow
+	Targets       []Target
+	Matrix        map[string][]string
+	FileSD        *FileSD `yaml:"file_sd"`
+	DNSSD         *DNSSD  `yaml:"dns_sd"`
+	vars          Replacement
 	ok            jobparser.Evaluatable
 	state         State
+	since         time.Time
+	transition    *Transition
 	timeAtState   int
 }
 
@@ -55,49 +68,197 @@ func (j *Job) Check(validAlerters []string) error {
 		j.OkAfter = 1
 	}
 
+	for k, v := range j.Matrix {
+		if len(v) == 0 {
+			return fmt.Errorf("Job.Matrix '%s' cannot be empty", k)
+		}
+	}
+
+	if j.FileSD != nil && j.DNSSD != nil {
+		return fmt.Errorf("Job.FileSD and Job.DNSSD cannot both be used")
+	}
+	if d := j.discoverer(); d != nil {
+		if j.Targets != nil {
+			return fmt.Errorf("Job.Targets cannot be used with discovery")
+		}
+		err := d.Check()
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, m := range j.Maintenance {
+		err := m.Check()
+		if err != nil {
+			return fmt.Errorf("Job.Maintenance[%d] %w", i, err)
+		}
+	}
+
 	for n, t := range j.Tests {
 		err := t.Check()
 		if err != nil {
 			return fmt.Errorf("Test: '%s' %w", n, err)
 		}
 	}
-	for _, a := range j.Alerters {
-		found := false
-		for _, va := range validAlerters {
-			if a == va {
-				found = true
-			}
-		}
-		if !found {
-			return fmt.Errorf("'%s' Alerter was not found", a)
-		}
+	err = checkAlerters(j.Alerters, validAlerters)
+	if err != nil {
+		return err
 	}
 	j.state = NoDataState
+	j.since = time.Now()
 	j.timeAtState = 1
 	return nil
 }
 
+// discoverer returns the Discoverer used to find this Job's Targets, or nil if
+// they are static.
+func (j *Job) discoverer() Discoverer {
+	if j.FileSD != nil {
+		return j.FileSD
+	}
+	if j.DNSSD != nil {
+		return j.DNSSD
+	}
+	return nil
+}
+
+// targets returns the Targets this Job should be expanded with, or nil if the
+// Job is already concrete.
+func (j *Job) targets() []Target {
+	if len(j.Matrix) > 0 {
+		targets := j.Targets
+		if len(targets) == 0 {
+			// A matrix on its own expands a single empty Target
+			targets = []Target{{}}
+		}
+		return expandMatrix(targets, j.Matrix)
+	}
+	return j.Targets
+}
+
+// expand creates a concrete Job from this one using the fields of the Target
+// as template variables. The context holds the secrets for the templates.
+func (j *Job) expand(ctx context.Context, t Target) (*Job, error) {
+	job := *j
+	job.Targets = nil
+	job.Matrix = nil
+
+	job.vars = make(Replacement, len(j.vars)+len(t))
+	for k, v := range j.vars {
+		job.vars[k] = v
+	}
+	for k, v := range t {
+		job.vars[k] = v
+	}
+
+	job.Values = make(map[string]string, len(t)+len(j.Values))
+	for k, v := range t {
+		job.Values[k] = v
+	}
+	for k, v := range j.Values {
+		value, err := job.vars.Render(ctx, k, v)
+		if err != nil {
+			return nil, fmt.Errorf("Job.Values '%s' Error: %w", k, err)
+		}
+		job.Values[k] = value
+	}
+
+	job.Labels = make(map[string]string, len(j.Labels))
+	for k, v := range j.Labels {
+		label, err := job.vars.Render(ctx, k, v)
+		if err != nil {
+			return nil, fmt.Errorf("Job.Labels '%s' Error: %w", k, err)
+		}
+		job.Labels[k] = label
+	}
+
+	return &job, nil
+}
+
+// JobResult contains the details of a single run of a Job
+type JobResult struct {
+	State      State
+	PrevState  State
+	Since      time.Time
+	Time       time.Time
+	Duration   time.Duration
+	Tests      map[string]TestResult
+	Transition *Transition
+	Err        error
+}
+
+// Down returns whether the Job counts as down for its availability: while it
+// is Alerting, or has no data because a Test couldn't be run, such as when the
+// host is unreachable
+func (r JobResult) Down() bool {
+	if r.State == AlertingState {
+		return true
+	}
+	if r.State != NoDataState {
+		return false
+	}
+	for _, t := range r.Tests {
+		if t.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (j *Job) Run(name string, ctx context.Context, alerts chan Alert) {
 	log.Info().
 		Str("job", name).
 		Int("no_tests", len(j.Tests)).
 		Msg("Loading job")
+	claimJobMetrics(name, j)
+	defer forgetJobMetrics(name, j)
+	defer func() {
+		for _, l := range listeners(ctx) {
+			l.JobStopped(name)
+		}
+	}()
 
 	for {
 		log.Info().
 			Str("job", name).
 			Msg("Job starting")
-		v, err := j.run(name, ctx)
+		res := j.run(name, ctx)
+		if ctx.Err() != nil {
+			// The job was stopped while running so the result is meaningless
+			return
+		}
 		log.Info().
 			Str("job", name).
-			Str("result", v.String()).
-			Err(err).
+			Str("result", res.State.String()).
+			Err(res.Err).
 			Msg("Job finished")
+		jobRuns.WithLabelValues(name).Inc()
+		if res.Err != nil {
+			jobErrors.WithLabelValues(name).Inc()
+		}
+		jobState.WithLabelValues(name).Set(float64(res.State))
+		if res.State != res.PrevState {
+			log.Info().
+				Str("job", name).
+				Str("from", res.PrevState.String()).
+				Str("to", res.State.String()).
+				Str("expression", res.Transition.Expression).
+				Strs("failed", res.Transition.Failed).
+				Str("reason", res.Transition.Reason()).
+				Msg("Job state changed")
+		}
+		for _, l := range listeners(ctx) {
+			l.JobFinished(name, j, res)
+		}
 		alerts <- Alert{
-			Job:      name,
-			State:    v,
-			Alerters: j.Alerters,
-			Values:   j.Values,
+			Job:         name,
+			State:       res.State,
+			Alerters:    j.Alerters,
+			Values:      j.Values,
+			Labels:      j.Labels,
+			Maintenance: maintenance(j.Maintenance, time.Now()),
+			Tests:       res.Tests,
+			Transition:  res.Transition,
 		}
 
 		select {
@@ -108,11 +269,30 @@ func (j *Job) Run(name string, ctx context.Context, alerts chan Alert) {
 	}
 }
 
-func (j *Job) run(jobName string, ctx context.Context) (State, error) {
+func (j *Job) run(jobName string, ctx context.Context) (result JobResult) {
+	result = JobResult{
+		Time:  time.Now(),
+		Tests: make(map[string]TestResult, len(j.Tests)),
+	}
+	defer func() {
+		result.Duration = time.Since(result.Time)
+	}()
+
+	ctx, span := tracer().Start(ctx, "isup.job", trace.WithAttributes(
+		attribute.String("isup.job", jobName),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("isup.state", result.State.String()))
+		if result.Err != nil {
+			span.SetStatus(codes.Error, redact.String(result.Err.Error()))
+		}
+		span.End()
+	}()
+
 	var wg sync.WaitGroup
 	resC := make(chan struct {
 		string
-		State
+		TestResult
 	})
 
 	for n, t := range j.Tests {
@@ -124,17 +304,42 @@ func (j *Job) run(jobName string, ctx context.Context) (State, error) {
 				Str("job", jobName).
 				Str("test", name).
 				Msg("Test starting")
-			v, err := test.Run(ctx)
+			ctx, span := tracer().Start(ctx, "isup.test", trace.WithAttributes(
+				attribute.String("isup.job", jobName),
+				attribute.String("isup.test", name),
+			))
+			v, err := test.Run(ctx, j.vars)
+			v.Err = err
+			span.SetAttributes(
+				attribute.String("isup.state", v.State.String()),
+				attribute.Int("http.status_code", v.StatusCode),
+			)
+			if err != nil {
+				span.SetStatus(codes.Error, redact.String(err.Error()))
+			}
+			span.End()
 			log.Info().
 				Str("job", jobName).
 				Str("test", name).
-				Str("result", v.String()).
+				Str("result", v.State.String()).
+				Int("status_code", v.StatusCode).
+				Dur("duration", v.Duration).
 				Err(err).
 				Msg("Test finished")
 
+			testRuns.WithLabelValues(jobName, name).Inc()
+			if v.State == NoDataState {
+				testErrors.WithLabelValues(jobName, name).Inc()
+			} else {
+				requestDuration.WithLabelValues(jobName, name).Observe(v.Duration.Seconds())
+			}
+			testState.WithLabelValues(jobName, name).Set(float64(v.State))
+			testStatusCode.WithLabelValues(jobName, name).Set(float64(v.StatusCode))
+			testLatency.WithLabelValues(jobName, name).Set(v.Duration.Seconds())
+
 			resC <- struct {
 				string
-				State
+				TestResult
 			}{name, v}
 		}(n, *t)
 	}
@@ -146,17 +351,41 @@ func (j *Job) run(jobName string, ctx context.Context) (State, error) {
 	}()
 
 	// Collect results and wait for channel close
+	noData := false
 	res := jobparser.Values{}
 	for r := range resC {
+		result.Tests[r.string] = r.TestResult
 		if r.State == NoDataState {
-			j.state = NoDataState
-			j.timeAtState = 0
-			return NoDataState, nil
+			noData = true
 		}
 		res[r.string] = r.State == OkState
 	}
 
+	prevState := j.state
+	defer func() {
+		if j.state != prevState {
+			j.since = time.Now()
+			j.transition = newTransition(*j.Ok, prevState, j.state, j.since, result)
+		}
+		result.Transition = j.transition
+		result.State = j.state
+		result.PrevState = prevState
+		result.Since = j.since
+	}()
+
+	if noData {
+		j.state = NoDataState
+		j.timeAtState = 0
+		return result
+	}
+
 	ok, err := j.ok.Evaluate(&res)
+	result.Err = err
+	if ok {
+		jobLastResult.WithLabelValues(jobName).Set(1)
+	} else {
+		jobLastResult.WithLabelValues(jobName).Set(0)
+	}
 
 	switch j.state {
 	case NoDataState:
@@ -217,5 +446,5 @@ func (j *Job) run(jobName string, ctx context.Context) (State, error) {
 		}
 	}
 
-	return j.state, err
+	return result
 }
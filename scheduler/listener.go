@@ -0,0 +1,23 @@
+package scheduler
+
+import (
+	"context"
+)
+
+// Listener is notified about the results of every Job
+type Listener interface {
+	JobFinished(name string, job *Job, result JobResult)
+	JobStopped(name string)
+}
+
+// AlertListener is implemented by Listeners that are also notified about every
+// Alert that an Alerter attempts to send
+type AlertListener interface {
+	AlertSent(alerter string, alert Alert, err error)
+}
+
+// listeners returns the Listeners registered on the context
+func listeners(ctx context.Context) []Listener {
+	l, _ := ctx.Value("listeners").([]Listener)
+	return l
+}
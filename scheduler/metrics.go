@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	jobState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "isup",
+		Name:      "job_state",
+		Help:      "Current state of the job (0=Ok, 1=Pending, 2=Alerting, 3=No_Data)",
+	}, []string{"job"})
+	jobLastResult = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "isup",
+		Name:      "job_last_result",
+		Help:      "Whether the job's ok statement passed on the last run",
+	}, []string{"job"})
+	jobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "job_runs_total",
+		Help:      "Number of times the job has run",
+	}, []string{"job"})
+	jobErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "job_errors_total",
+		Help:      "Number of job runs that returned an error",
+	}, []string{"job"})
+
+	testState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "isup",
+		Name:      "test_state",
+		Help:      "State of the test on the last run (0=Ok, 2=Alerting, 3=No_Data)",
+	}, []string{"job", "test"})
+	testStatusCode = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "isup",
+		Name:      "test_status_code",
+		Help:      "HTTP status code returned on the last run of the test",
+	}, []string{"job", "test"})
+	testLatency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "isup",
+		Name:      "test_latency_seconds",
+		Help:      "Duration of the request on the last run of the test",
+	}, []string{"job", "test"})
+	testRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "test_runs_total",
+		Help:      "Number of times the test has run",
+	}, []string{"job", "test"})
+	testErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "test_errors_total",
+		Help:      "Number of test runs that returned an error",
+	}, []string{"job", "test"})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "isup",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests made by tests",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job", "test"})
+
+	alertsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "alerts_sent_total",
+		Help:      "Number of alerts sent successfully",
+	}, []string{"alerter", "job"})
+	alertsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "alerts_failed_total",
+		Help:      "Number of attempts to send an alert that failed",
+	}, []string{"alerter", "job"})
+	alertsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "isup",
+		Name:      "alerts_dropped_total",
+		Help:      "Number of alerts given up on after failing to send",
+	}, []string{"alerter", "job"})
+)
+
+// jobMetricOwners is the Job whose metrics are published under each name.
+// When the config is reloaded the new Job starts before the old one stops, so
+// the old Job must not remove the new one's metrics.
+var (
+	jobMetricOwnersMutex sync.Mutex
+	jobMetricOwners      = make(map[string]*Job)
+)
+
+// claimJobMetrics makes j the Job whose metrics are published under name,
+// removing those of any tests the previous Job had that j doesn't
+func claimJobMetrics(name string, j *Job) {
+	jobMetricOwnersMutex.Lock()
+	defer jobMetricOwnersMutex.Unlock()
+
+	if prev, ok := jobMetricOwners[name]; ok && prev != j {
+		for t := range prev.Tests {
+			if _, exist := j.Tests[t]; !exist {
+				forgetTestMetrics(name, t)
+			}
+		}
+	}
+	jobMetricOwners[name] = j
+}
+
+// forgetJobMetrics removes the metrics of a Job that is no longer running,
+// unless another Job with the same name has started since
+func forgetJobMetrics(name string, j *Job) {
+	jobMetricOwnersMutex.Lock()
+	defer jobMetricOwnersMutex.Unlock()
+
+	if jobMetricOwners[name] != j {
+		return
+	}
+	delete(jobMetricOwners, name)
+
+	jobState.DeleteLabelValues(name)
+	jobLastResult.DeleteLabelValues(name)
+	jobRuns.DeleteLabelValues(name)
+	jobErrors.DeleteLabelValues(name)
+
+	for t := range j.Tests {
+		forgetTestMetrics(name, t)
+	}
+}
+
+func forgetTestMetrics(name, test string) {
+	testState.DeleteLabelValues(name, test)
+	testStatusCode.DeleteLabelValues(name, test)
+	testLatency.DeleteLabelValues(name, test)
+	testRuns.DeleteLabelValues(name, test)
+	testErrors.DeleteLabelValues(name, test)
+	requestDuration.DeleteLabelValues(name, test)
+}
@@ -0,0 +1,353 @@
+package scheduler
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	bolt "go.etcd.io/bbolt"
+
+	"isup/redact"
+)
+
+var alertersBucket = []byte("alerters")
+
+// Outbox keeps alerts on disk until they are sent so that they survive a
+// restart, and appends the alerts that could not be sent to a dead letter log.
+// The database is held open by the Router while it runs. It holds the real
+// values of the alerts, including any secrets, to send them again, so only its
+// owner can read it. Secrets are redacted from the dead letter log.
+type Outbox struct {
+	Path       *string
+	DeadLetter *string `yaml:"dead_letter"`
+	mu         sync.Mutex
+	db         *bolt.DB
+}
+
+func (o *Outbox) Check() error {
+	if o.Path == nil && o.DeadLetter == nil {
+		return fmt.Errorf("Outbox.Path or Outbox.DeadLetter is required")
+	}
+	for _, p := range []*string{o.Path, o.DeadLetter} {
+		if p == nil {
+			continue
+		}
+		err := os.MkdirAll(filepath.Dir(*p), 0744)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queuedAlert is an alert waiting to be sent
+type queuedAlert struct {
+	id       uint64
+	Queued   time.Time
+	Attempts int
+	Alert    Alert
+}
+
+// alertRecord is the stored form of a queuedAlert
+type alertRecord struct {
+	Queued     time.Time             `json:"queued"`
+	Attempts   int                   `json:"attempts"`
+	Job        string                `json:"job"`
+	State      string                `json:"state"`
+	Repeat     bool                  `json:"repeat,omitempty"`
+	Values     map[string]string     `json:"values,omitempty"`
+	Labels     map[string]string     `json:"labels,omitempty"`
+	Tests      map[string]testRecord `json:"tests,omitempty"`
+	Transition *transitionRecord     `json:"transition,omitempty"`
+}
+
+type testRecord struct {
+	State      string            `json:"state"`
+	StatusCode int               `json:"status_code"`
+	Duration   time.Duration     `json:"duration"`
+	Values     map[string]string `json:"values,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type transitionRecord struct {
+	Time       time.Time                    `json:"time"`
+	From       string                       `json:"from"`
+	To         string                       `json:"to"`
+	Expression string                       `json:"expression"`
+	Failed     []string                     `json:"failed"`
+	Errors     map[string]string            `json:"errors,omitempty"`
+	Values     map[string]map[string]string `json:"values,omitempty"`
+	Error      string                       `json:"error,omitempty"`
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func stringError(s string) error {
+	if s == "" {
+		return nil
+	}
+	return errors.New(s)
+}
+
+func (q *queuedAlert) record() alertRecord {
+	r := alertRecord{
+		Queued:   q.Queued,
+		Attempts: q.Attempts,
+		Job:      q.Alert.Job,
+		State:    q.Alert.State.String(),
+		Repeat:   q.Alert.Repeat,
+		Values:   q.Alert.Values,
+		Labels:   q.Alert.Labels,
+		Tests:    make(map[string]testRecord, len(q.Alert.Tests)),
+	}
+	for n, t := range q.Alert.Tests {
+		r.Tests[n] = testRecord{
+			State:      t.State.String(),
+			StatusCode: t.StatusCode,
+			Duration:   t.Duration,
+			Values:     t.Values,
+			Error:      errorString(t.Err),
+		}
+	}
+	if t := q.Alert.Transition; t != nil {
+		r.Transition = &transitionRecord{
+			Time:       t.Time,
+			From:       t.From.String(),
+			To:         t.To.String(),
+			Expression: t.Expression,
+			Failed:     t.Failed,
+			Errors:     t.Errors,
+			Values:     t.Values,
+			Error:      errorString(t.Err),
+		}
+	}
+	return r
+}
+
+func (r alertRecord) queuedAlert(id uint64) (*queuedAlert, error) {
+	state, err := parseState(r.State)
+	if err != nil {
+		return nil, err
+	}
+	q := &queuedAlert{
+		id:       id,
+		Queued:   r.Queued,
+		Attempts: r.Attempts,
+		Alert: Alert{
+			Job:    r.Job,
+			State:  state,
+			Repeat: r.Repeat,
+			Values: r.Values,
+			Labels: r.Labels,
+			Tests:  make(map[string]TestResult, len(r.Tests)),
+		},
+	}
+	for n, t := range r.Tests {
+		state, err := parseState(t.State)
+		if err != nil {
+			return nil, err
+		}
+		q.Alert.Tests[n] = TestResult{
+			State:      state,
+			StatusCode: t.StatusCode,
+			Duration:   t.Duration,
+			Values:     t.Values,
+			Err:        stringError(t.Error),
+		}
+	}
+	if t := r.Transition; t != nil {
+		from, err := parseState(t.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseState(t.To)
+		if err != nil {
+			return nil, err
+		}
+		q.Alert.Transition = &Transition{
+			Time:       t.Time,
+			From:       from,
+			To:         to,
+			Expression: t.Expression,
+			Failed:     t.Failed,
+			Errors:     t.Errors,
+			Values:     t.Values,
+			Err:        stringError(t.Error),
+		}
+	}
+	return q, nil
+}
+
+// open opens the database, which stays open until close is called
+func (o *Outbox) open() error {
+	if o == nil || o.Path == nil {
+		return nil
+	}
+
+	db, err := bolt.Open(*o.Path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return err
+	}
+	// The mode is only used when the file is created
+	err = os.Chmod(*o.Path, 0600)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	o.mu.Lock()
+	o.db = db
+	o.mu.Unlock()
+	return nil
+}
+
+// close closes the database once nothing is using it
+func (o *Outbox) close() {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.db == nil {
+		return
+	}
+	err := o.db.Close()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not close the outbox")
+	}
+	o.db = nil
+}
+
+// update runs fn in a read-write transaction with the bucket of the alerter.
+// Nothing is stored if the database isn't open.
+func (o *Outbox) update(alerter string, fn func(b *bolt.Bucket) error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.db == nil {
+		return nil
+	}
+
+	return o.db.Update(func(tx *bolt.Tx) error {
+		alerters, err := tx.CreateBucketIfNotExists(alertersBucket)
+		if err != nil {
+			return err
+		}
+		b, err := alerters.CreateBucketIfNotExists([]byte(alerter))
+		if err != nil {
+			return err
+		}
+		return fn(b)
+	})
+}
+
+func idKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// add stores the alert, giving it an id if it doesn't have one
+func (o *Outbox) add(alerter string, q *queuedAlert) error {
+	if o == nil || o.Path == nil {
+		return nil
+	}
+	return o.update(alerter, func(b *bolt.Bucket) error {
+		if q.id == 0 {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			q.id = id
+		}
+		data, err := json.Marshal(q.record())
+		if err != nil {
+			return err
+		}
+		return b.Put(idKey(q.id), data)
+	})
+}
+
+// remove deletes the alert once it has been sent or dropped
+func (o *Outbox) remove(alerter string, q *queuedAlert) error {
+	if o == nil || o.Path == nil || q.id == 0 {
+		return nil
+	}
+	return o.update(alerter, func(b *bolt.Bucket) error {
+		return b.Delete(idKey(q.id))
+	})
+}
+
+// pending returns the alerts that are still to be sent, oldest first as the
+// keys are in order
+func (o *Outbox) pending(alerter string) ([]*queuedAlert, error) {
+	if o == nil || o.Path == nil {
+		return nil, nil
+	}
+	alerts := make([]*queuedAlert, 0)
+	err := o.update(alerter, func(b *bolt.Bucket) error {
+		return b.ForEach(func(k, v []byte) error {
+			var r alertRecord
+			err := json.Unmarshal(v, &r)
+			if err != nil {
+				return err
+			}
+			q, err := r.queuedAlert(binary.BigEndian.Uint64(k))
+			if err != nil {
+				return err
+			}
+			alerts = append(alerts, q)
+			return nil
+		})
+	})
+	return alerts, err
+}
+
+// deadLetterEntry is a line of the dead letter log
+type deadLetterEntry struct {
+	Time    time.Time `json:"time"`
+	Alerter string    `json:"alerter"`
+	Error   string    `json:"error"`
+	alertRecord
+}
+
+// deadLetter appends an alert that could not be sent to the dead letter log
+func (o *Outbox) deadLetter(alerter string, q *queuedAlert, reason error) error {
+	if o == nil || o.DeadLetter == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(deadLetterEntry{
+		Time:        time.Now(),
+		Alerter:     alerter,
+		Error:       errorString(reason),
+		alertRecord: q.record(),
+	})
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	f, err := os.OpenFile(*o.DeadLetter, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(redact.Bytes(data), '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
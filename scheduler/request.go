@@ -8,6 +8,14 @@ import (
 	"os"
 	"strings"
 	"text/template"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"isup/redact"
 )
 
 func validMethod(method string) bool {
@@ -26,7 +34,7 @@ func validMethod(method string) bool {
 	return false
 }
 
-type Replacement map[string]string
+type Replacement map[string]interface{}
 
 func (r Replacement) WithEnv() Replacement {
 	for _, e := range os.Environ() {
@@ -39,6 +47,22 @@ func (r Replacement) WithEnv() Replacement {
 	return r
 }
 
+// Render executes text as a template using the Replacement as its data
+func (r Replacement) Render(ctx context.Context, name, text string) (string, error) {
+	var out bytes.Buffer
+
+	tmpl, err := template.New(name).Funcs(templateFuncs(ctx)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	err = tmpl.Execute(&out, r)
+	if err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
 type Request struct {
 	// Insecure    bool
 	Body        *string
@@ -66,24 +90,29 @@ func (r *Request) Check() error {
 }
 
 func (r *Request) Run(ctx context.Context, repl *Replacement) (*http.Response, error) {
+	render := func(name, text string) (string, error) {
+		if repl == nil {
+			return text, nil
+		}
+		return repl.Render(ctx, name, text)
+	}
+
 	var body bytes.Buffer
 
 	if r.Body != nil {
-		if repl != nil {
-			tmpl, err := template.New("body").Parse(*r.Body)
-			if err != nil {
-				return nil, err
-			}
-			err = tmpl.Execute(&body, repl)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			body = *bytes.NewBuffer([]byte(*r.Body))
+		b, err := render("body", *r.Body)
+		if err != nil {
+			return nil, err
 		}
+		body.WriteString(b)
+	}
+
+	url, err := render("url", *r.URL)
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequest(*r.Method, *r.URL, &body)
+	req, err := http.NewRequest(*r.Method, url, &body)
 	if err != nil {
 		return nil, err
 	}
@@ -91,16 +120,42 @@ func (r *Request) Run(ctx context.Context, repl *Replacement) (*http.Response, e
 	if len(r.QueryParams) > 0 {
 		q := req.URL.Query()
 		for k, v := range r.QueryParams {
-			q.Add(k, v)
+			param, err := render(k, v)
+			if err != nil {
+				return nil, err
+			}
+			q.Add(k, param)
 		}
 		req.URL.RawQuery = q.Encode()
 	}
 
 	for k, v := range r.Headers {
-		req.Header.Add(k, v)
+		header, err := render(k, v)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add(k, header)
 	}
 
+	ctx, span := tracer().Start(ctx, "isup.request",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", redact.String(req.URL.String())),
+		),
+	)
+	defer span.End()
+
+	// Let the backend correlate the request with the probe's trace
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	client := ctx.Value("http.client").(http.Client)
 	req = req.WithContext(ctx)
-	return client.Do(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, redact.String(err.Error()))
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	return resp, nil
 }
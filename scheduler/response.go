@@ -44,9 +44,11 @@ func (r *Response) Run(ctx context.Context, resp *http.Response) (*testparser.Va
 				switch v.Type {
 				case gjson.String:
 					num, _ := strconv.ParseFloat(v.Str, 64)
+					b, _ := strconv.ParseBool(v.Str)
 					r = testparser.Value{
-						StrValue: v.Str,
-						NumValue: num,
+						StrValue:  v.Str,
+						NumValue:  num,
+						BoolValue: b,
 					}
 				case gjson.Number:
 					str := strconv.FormatFloat(v.Num, 'g', -1, 64)
@@ -54,6 +56,16 @@ func (r *Response) Run(ctx context.Context, resp *http.Response) (*testparser.Va
 						StrValue: str,
 						NumValue: v.Num,
 					}
+				case gjson.True, gjson.False:
+					num := 0.0
+					if v.Bool() {
+						num = 1
+					}
+					r = testparser.Value{
+						StrValue:  strconv.FormatBool(v.Bool()),
+						NumValue:  num,
+						BoolValue: v.Bool(),
+					}
 				}
 
 				log.Debug().Str("name", n).Str("path", p).Interface("result", r).Msg("extraction_result")
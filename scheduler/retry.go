@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retry configures how many times an alert is sent before it is dropped, and
+// how long to wait between attempts
+type Retry struct {
+	Attempts   *int
+	Backoff    *time.Duration
+	MaxBackoff *time.Duration `yaml:"max_backoff"`
+}
+
+func (r *Retry) Check() error {
+	if r.Attempts == nil {
+		attempts := 3
+		r.Attempts = &attempts
+	}
+	if *r.Attempts < 1 {
+		return fmt.Errorf("Retry.Attempts must be at least 1")
+	}
+	if r.Backoff == nil {
+		backoff := time.Second
+		r.Backoff = &backoff
+	}
+	if r.MaxBackoff == nil {
+		maxBackoff := time.Minute
+		r.MaxBackoff = &maxBackoff
+	}
+	if *r.Backoff <= 0 || *r.MaxBackoff < *r.Backoff {
+		return fmt.Errorf("Retry.Backoff must be positive and no more than Retry.MaxBackoff")
+	}
+	return nil
+}
+
+// delay returns how long to wait after the given number of failed attempts,
+// doubling each time up to the maximum backoff
+func (r *Retry) delay(attempts int) time.Duration {
+	delay := *r.Backoff
+	for i := 1; i < attempts && delay < *r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > *r.MaxBackoff {
+		delay = *r.MaxBackoff
+	}
+	return delay
+}
+
+// retryable returns whether an alert that failed with the status code may
+// succeed if it is sent again. Client errors, other than rate limiting, are
+// not retried.
+func retryable(statusCode int) bool {
+	return statusCode < 400 || statusCode >= 500 || statusCode == 429
+}
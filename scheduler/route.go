@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Matchers select jobs by their labels, including a job label with the name of
+// the job
+type Matchers struct {
+	Match   map[string]string `json:"match,omitempty"`
+	MatchRE map[string]string `yaml:"match_re" json:"match_re,omitempty"`
+	matchRE map[string]*regexp.Regexp
+}
+
+func (m *Matchers) Check() error {
+	m.matchRE = make(map[string]*regexp.Regexp, len(m.MatchRE))
+	for k, v := range m.MatchRE {
+		re, err := regexp.Compile("^(?:" + v + ")$")
+		if err != nil {
+			return fmt.Errorf("MatchRE '%s' Error: %w", k, err)
+		}
+		m.matchRE[k] = re
+	}
+	return nil
+}
+
+// empty returns whether there are no matchers, and so everything matches
+func (m *Matchers) empty() bool {
+	return len(m.Match) == 0 && len(m.MatchRE) == 0
+}
+
+// matches returns whether the labels match. A missing label has an empty
+// value.
+func (m *Matchers) matches(labels map[string]string) bool {
+	for k, v := range m.Match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	for k, re := range m.matchRE {
+		if !re.MatchString(labels[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// alertLabels returns the labels of the alert's job that are matched against
+func alertLabels(alert Alert) map[string]string {
+	labels := make(map[string]string, len(alert.Labels)+1)
+	labels["job"] = alert.Job
+	for k, v := range alert.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// Route sends the alerts of jobs whose labels match to its alerters. Like
+// Alertmanager, an alert is sent to the alerters of the deepest matching
+// routes, and stops at the first matching sibling unless it has Continue set.
+// A route without alerters uses those of its parent.
+type Route struct {
+	Matchers `yaml:",inline"`
+	Alerters []string
+	Continue bool
+	Routes   []*Route
+}
+
+func (r *Route) Check(validAlerters []string) error {
+	if len(r.Alerters) == 0 && len(r.Routes) == 0 {
+		return fmt.Errorf("Route.Alerters or Route.Routes is required")
+	}
+
+	err := r.Matchers.Check()
+	if err != nil {
+		return fmt.Errorf("Route.%w", err)
+	}
+
+	err = checkAlerters(r.Alerters, validAlerters)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range r.Routes {
+		err := c.Check(validAlerters)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAlerters returns an error if any of the alerters are not valid
+func checkAlerters(alerters, validAlerters []string) error {
+	for _, a := range alerters {
+		found := false
+		for _, va := range validAlerters {
+			if a == va {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("'%s' Alerter was not found", a)
+		}
+	}
+	return nil
+}
+
+// route returns the alerters for the labels, and whether the route matched. A
+// route without alerters of its own uses those it inherits from the nearest
+// route above it that has them, so that a matching route always has alerters
+// for the alerts that none of its child routes match.
+func (r *Route) route(labels map[string]string, inherited []string) ([]string, bool) {
+	if !r.matches(labels) {
+		return nil, false
+	}
+	if len(r.Alerters) > 0 {
+		inherited = r.Alerters
+	}
+
+	alerters := make([]string, 0)
+	matched := false
+	for _, c := range r.Routes {
+		a, ok := c.route(labels, inherited)
+		if !ok {
+			continue
+		}
+		matched = true
+		alerters = append(alerters, a...)
+		if !c.Continue {
+			break
+		}
+	}
+
+	if !matched {
+		alerters = append(alerters, inherited...)
+	}
+	return alerters, true
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRouterRoute(t *testing.T) {
+	def := true
+	r := NewRouter()
+	r.Alerters = map[string]*Alerter{
+		"default": {Default: &def},
+	}
+	r.Routes = []*Route{
+		{
+			Matchers: Matchers{Match: map[string]string{"team": "db"}},
+			Alerters: []string{"dba"},
+			Continue: true,
+		},
+		{
+			// Groups its child routes without alerters of its own
+			Matchers: Matchers{MatchRE: map[string]string{"severity": "critical|page"}},
+			Routes: []*Route{
+				{
+					Matchers: Matchers{Match: map[string]string{"env": "staging"}},
+					Alerters: []string{"chat"},
+				},
+			},
+		},
+		{
+			Matchers: Matchers{Match: map[string]string{"team": "web"}},
+			Alerters: []string{"web"},
+			Routes: []*Route{
+				{
+					Matchers: Matchers{Match: map[string]string{"env": "staging"}},
+					Routes: []*Route{
+						{
+							Matchers: Matchers{Match: map[string]string{"region": "eu"}},
+							Alerters: []string{"eu"},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, route := range r.Routes {
+		err := route.Check([]string{"default", "dba", "chat", "web", "eu"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []string
+	}{
+		{"no match", map[string]string{"team": "app"}, []string{"default"}},
+		{"continue", map[string]string{"team": "db", "severity": "page", "env": "staging"}, []string{"dba", "chat"}},
+		{"grouping only", map[string]string{"severity": "critical", "env": "prod"}, []string{"default"}},
+		{"nearest ancestor", map[string]string{"team": "web", "env": "staging"}, []string{"web"}},
+		{"deepest", map[string]string{"team": "web", "env": "staging", "region": "eu"}, []string{"eu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.route(Alert{Job: "job", Labels: tt.labels})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("route() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteCheck(t *testing.T) {
+	r := &Route{Matchers: Matchers{Match: map[string]string{"team": "db"}}}
+	if err := r.Check(nil); err == nil {
+		t.Errorf("Check() of a route without alerters or routes succeeded")
+	}
+}
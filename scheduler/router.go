@@ -2,45 +2,125 @@ package scheduler
 
 import (
 	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
+// Router sends the alerts of every Job to their Alerters. It is also a
+// Listener so that it can forget the jobs that are stopped.
 type Router struct {
-	Alerts   chan Alert
-	Alerters map[string]*Alerter
+	Alerts      chan Alert
+	Alerters    map[string]*Alerter
+	Escalations map[string]*Escalation
+	Routes      []*Route
+	Silences    *Silences
+	done        chan struct{}
 }
 
 func NewRouter() *Router {
 	alerts := make(chan Alert, 100)
 	return &Router{
 		Alerts: alerts,
+		done:   make(chan struct{}),
+	}
+}
+
+func (r *Router) JobFinished(name string, job *Job, result JobResult) {
+}
+
+// JobStopped queues the job to be forgotten after any alerts it already sent,
+// unless the router has stopped
+func (r *Router) JobStopped(name string) {
+	select {
+	case r.Alerts <- Alert{Job: name, stopped: true}:
+	case <-r.done:
 	}
 }
 
+// Run routes alerts until the context is done, then waits for the alerters to
+// stop
 func (r *Router) Run(ctx context.Context) error {
-	chans := make(map[string]chan Alert, len(r.Alerters))
+	defer close(r.done)
+
+	outbox, _ := ctx.Value("outbox").(*Outbox)
+	err := outbox.open()
+	if err != nil {
+		log.Error().Err(err).Msg("Could not open the outbox")
+	}
+	defer outbox.close()
 
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	chans := make(map[string]chan Alert, len(r.Alerters))
 	for n, a := range r.Alerters {
 		c := make(chan Alert, 100)
 		chans[n] = c
-		go a.Run(n, ctx, c)
+		wg.Add(1)
+		go func(n string, a *Alerter) {
+			defer wg.Done()
+			a.Run(n, ctx, c)
+		}(n, a)
 	}
 
+	// The progress of each job through each escalation
+	escalations := make(map[string]map[string]*escalationState, len(r.Escalations))
+	for n := range r.Escalations {
+		escalations[n] = make(map[string]*escalationState)
+	}
+	ticker := time.NewTicker(escalationCheck)
+	defer ticker.Stop()
+
+	// The state of each job when it was last logged as having no alerters
+	unroutedJobs := make(map[string]State)
+
 	for {
 		select {
 		case alert := <-r.Alerts:
-			// If no alerters then use default ones
-			if alert.Alerters == nil {
-				alert.Alerters = make([]string, 0, len(r.Alerters))
-				for n, a := range r.Alerters {
-					if *a.Default {
-						alert.Alerters = append(alert.Alerters, n)
-					}
+			if alert.stopped {
+				// A job that is started again escalates from the start
+				for _, states := range escalations {
+					delete(states, alert.Job)
 				}
+				delete(unroutedJobs, alert.Job)
+				continue
+			}
+
+			silenced := r.silenced(alert)
+
+			// If no alerters then route by labels, or use default ones
+			if alert.Alerters == nil {
+				alert.Alerters = r.route(alert)
+			}
+			// Without any alerters there is nothing to warn about
+			if len(alert.Alerters) == 0 && len(r.Alerters) > 0 && !silenced {
+				unrouted(alert, unroutedJobs)
+			} else {
+				delete(unroutedJobs, alert.Job)
 			}
 
 			for _, a := range alert.Alerters {
-				c := chans[a]
-				c <- alert
+				// Escalations keep track of silenced jobs so that they don't
+				// escalate a stale alert when the silence ends
+				if _, ok := r.Escalations[a]; ok {
+					r.escalate(a, alert, silenced, chans, escalations[a])
+					continue
+				}
+				if !silenced {
+					chans[a] <- alert
+				}
+			}
+
+		case <-ticker.C:
+			for n, states := range escalations {
+				for _, st := range states {
+					if r.silenced(st.alert) {
+						continue
+					}
+					r.advance(n, st, chans)
+				}
 			}
 
 		case <-ctx.Done():
@@ -48,3 +128,70 @@ func (r *Router) Run(ctx context.Context) error {
 		}
 	}
 }
+
+// route returns the alerters for an alert from the routes that match its job's
+// labels, or the default alerters if none do
+func (r *Router) route(alert Alert) []string {
+	defaults := make([]string, 0, len(r.Alerters))
+	for n, a := range r.Alerters {
+		if *a.Default {
+			defaults = append(defaults, n)
+		}
+	}
+	if len(r.Routes) == 0 {
+		return defaults
+	}
+
+	labels := alertLabels(alert)
+	root := &Route{
+		Alerters: defaults,
+		Routes:   r.Routes,
+	}
+	routed, _ := root.route(labels, defaults)
+
+	// An alerter may be matched by more than one route
+	alerters := make([]string, 0, len(routed))
+	seen := make(map[string]bool, len(routed))
+	for _, a := range routed {
+		if !seen[a] {
+			seen[a] = true
+			alerters = append(alerters, a)
+		}
+	}
+	return alerters
+}
+
+// unrouted logs an alert that has no alerters to be sent to, once for each
+// state of its job
+func unrouted(alert Alert, logged map[string]State) {
+	if state, ok := logged[alert.Job]; ok && state == alert.State {
+		return
+	}
+	logged[alert.Job] = alert.State
+	log.Warn().
+		Str("job", alert.Job).
+		Str("state", alert.State.String()).
+		Msg("Alert has no alerters")
+}
+
+// silenced returns whether the alert's job is in a maintenance window or
+// matches a silence, in which case it is not sent
+func (r *Router) silenced(alert Alert) bool {
+	if alert.Maintenance != "" {
+		log.Debug().
+			Str("job", alert.Job).
+			Str("maintenance", alert.Maintenance).
+			Msg("Alert silenced")
+		return true
+	}
+
+	silences := r.Silences.Silenced(alert, time.Now())
+	if len(silences) > 0 {
+		log.Debug().
+			Str("job", alert.Job).
+			Strs("silences", silences).
+			Msg("Alert silenced")
+		return true
+	}
+	return false
+}
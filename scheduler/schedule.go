@@ -2,34 +2,138 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
 type Schedule struct {
-	Jobs map[string]*Job
+	Jobs      map[string]*Job
+	templates map[string]*Job
 }
 
-func (s *Schedule) Check(validAlerters []string) error {
-	for _, j := range s.Jobs {
+// Check checks the Jobs and expands those with static Targets. The context
+// holds the secrets that can be used in their values and labels.
+func (s *Schedule) Check(ctx context.Context, validAlerters []string) error {
+	jobs := make(map[string]*Job, len(s.Jobs))
+	s.templates = make(map[string]*Job)
+
+	for n, j := range s.Jobs {
 		err := j.Check(validAlerters)
 		if err != nil {
 			return err
 		}
+
+		// Discovered jobs are expanded once the schedule is running
+		if j.discoverer() != nil {
+			s.templates[n] = j
+			continue
+		}
+
+		targets := j.targets()
+		if targets == nil {
+			if _, exist := jobs[n]; exist {
+				return fmt.Errorf("Job '%s' is defined more than once", n)
+			}
+			jobs[n] = j
+			continue
+		}
+
+		for _, t := range targets {
+			name := t.JobName(n, j.Matrix)
+			if _, exist := jobs[name]; exist {
+				return fmt.Errorf("Job '%s' is defined more than once", name)
+			}
+			job, err := j.expand(ctx, t)
+			if err != nil {
+				return fmt.Errorf("Job: '%s' %w", name, err)
+			}
+			jobs[name] = job
+		}
 	}
+
+	s.Jobs = jobs
 	return nil
 }
 
 func (s *Schedule) Run(ctx context.Context, alerts chan Alert) {
 	log.Info().
 		Int("no_jobs", len(s.Jobs)).
+		Int("no_discovered_jobs", len(s.templates)).
 		Msg("Loading schedule")
 
 	for n, j := range s.Jobs {
 		go j.Run(n, ctx, alerts)
 	}
 
+	for n, j := range s.templates {
+		go s.discover(n, j, ctx, alerts)
+	}
+
 	select {
 	case <-ctx.Done():
 	}
 }
+
+// discover periodically finds the Targets of a Job, starting a Job for each new
+// Target and stopping the Jobs of any that have disappeared.
+func (s *Schedule) discover(name string, template *Job, ctx context.Context, alerts chan Alert) {
+	d := template.discoverer()
+	running := make(map[string]context.CancelFunc)
+
+	for {
+		targets, err := d.Discover(ctx)
+		if err != nil {
+			log.Warn().
+				Str("job", name).
+				Err(err).
+				Msg("Discovery failed")
+		} else {
+			if len(template.Matrix) > 0 {
+				targets = expandMatrix(targets, template.Matrix)
+			}
+
+			found := make(map[string]Target, len(targets))
+			for _, t := range targets {
+				found[t.JobName(name, template.Matrix)] = t
+			}
+
+			for n, cancel := range running {
+				if _, exist := found[n]; !exist {
+					log.Info().
+						Str("job", n).
+						Msg("Removing discovered job")
+					cancel()
+					delete(running, n)
+				}
+			}
+
+			for n, t := range found {
+				if _, exist := running[n]; exist {
+					continue
+				}
+				job, err := template.expand(ctx, t)
+				if err != nil {
+					log.Warn().
+						Str("job", n).
+						Err(err).
+						Msg("Could not create discovered job")
+					continue
+				}
+				log.Info().
+					Str("job", n).
+					Msg("Adding discovered job")
+				jobCtx, cancel := context.WithCancel(ctx)
+				running[n] = cancel
+				go job.Run(n, jobCtx, alerts)
+			}
+		}
+
+		select {
+		case <-time.After(d.Interval()):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Secret is a value read from a file, such as a Docker or Kubernetes secret
+// mount, that can be used in templates with {{secret "name"}}
+type Secret struct {
+	File  *string
+	value string
+}
+
+func (s *Secret) Check() error {
+	if s.File == nil {
+		return fmt.Errorf("Secret.File cannot be empty")
+	}
+
+	data, err := ioutil.ReadFile(*s.File)
+	if err != nil {
+		return err
+	}
+	s.value = strings.TrimRight(string(data), "\r\n")
+
+	return nil
+}
+
+type Secrets map[string]*Secret
+
+func (s Secrets) Check() error {
+	for n, secret := range s {
+		err := secret.Check()
+		if err != nil {
+			return fmt.Errorf("Secret: '%s' %w", n, err)
+		}
+	}
+	return nil
+}
+
+// Get returns the value of the named Secret
+func (s Secrets) Get(name string) (string, error) {
+	secret, ok := s[name]
+	if !ok {
+		return "", fmt.Errorf("Secret '%s' not found", name)
+	}
+	return secret.value, nil
+}
+
+// Values returns the value of every Secret so that they can be redacted
+func (s Secrets) Values() []string {
+	values := make([]string, 0, len(s))
+	for _, secret := range s {
+		if secret.value != "" {
+			values = append(values, secret.value)
+		}
+	}
+	return values
+}
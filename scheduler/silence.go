@@ -0,0 +1,288 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// Silence stops the alerts of the jobs it matches from being sent between
+// StartsAt and EndsAt. The jobs keep running and recording their state.
+type Silence struct {
+	ID        string `json:"id"`
+	Matchers  `yaml:",inline"`
+	StartsAt  time.Time `yaml:"starts_at" json:"starts_at"`
+	EndsAt    time.Time `yaml:"ends_at" json:"ends_at"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `yaml:"created_by" json:"created_by,omitempty"`
+	Source    string    `yaml:"-" json:"source"`
+}
+
+func (s *Silence) Check() error {
+	if s.Matchers.empty() {
+		return fmt.Errorf("Silence.Match or Silence.MatchRE is required")
+	}
+	err := s.Matchers.Check()
+	if err != nil {
+		return fmt.Errorf("Silence.%w", err)
+	}
+	if s.EndsAt.IsZero() {
+		return fmt.Errorf("Silence.EndsAt cannot be empty")
+	}
+	if !s.StartsAt.IsZero() && !s.StartsAt.Before(s.EndsAt) {
+		return fmt.Errorf("Silence.StartsAt must be before Silence.EndsAt")
+	}
+	if s.Comment == "" {
+		return fmt.Errorf("Silence.Comment cannot be empty")
+	}
+	return nil
+}
+
+// active returns whether the silence applies at t
+func (s *Silence) active(t time.Time) bool {
+	return !t.Before(s.StartsAt) && t.Before(s.EndsAt)
+}
+
+// SilenceStore keeps the silences added through the API across restarts
+type SilenceStore interface {
+	Load() ([]*Silence, error)
+	Save(silences []*Silence) error
+}
+
+// Silences holds the silences from the config along with those added through
+// the API. It lives for as long as isup runs, so that silences added through
+// the API are kept when the config is reloaded, and they are saved to the
+// store if there is one so that they are also kept when isup is restarted.
+type Silences struct {
+	mutex  sync.RWMutex
+	static []*Silence
+	added  map[string]*Silence
+	store  SilenceStore
+}
+
+func NewSilences() *Silences {
+	return &Silences{
+		added: make(map[string]*Silence),
+	}
+}
+
+// SetStatic replaces the silences from the config
+func (s *Silences) SetStatic(silences []*Silence) {
+	for i, silence := range silences {
+		silence.Source = "config"
+		if silence.ID == "" {
+			silence.ID = fmt.Sprintf("config-%d", i)
+		}
+	}
+
+	s.mutex.Lock()
+	s.static = silences
+	s.mutex.Unlock()
+}
+
+// SetStore loads the silences that haven't ended from the store, and saves
+// the silences added through the API to it from now on. A nil store keeps them
+// in memory only.
+func (s *Silences) SetStore(store SilenceStore) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Don't overwrite silences that couldn't be loaded
+	s.store = nil
+	if store == nil {
+		return nil
+	}
+
+	stored, err := store.Load()
+	if err != nil {
+		return err
+	}
+	s.store = store
+	for _, silence := range stored {
+		err := silence.Check()
+		if err != nil {
+			log.Warn().Str("silence", silence.ID).Err(err).Msg("Ignoring stored silence")
+			continue
+		}
+		silence.Source = "api"
+		if _, ok := s.added[silence.ID]; !ok {
+			s.added[silence.ID] = silence
+		}
+	}
+	s.prune(time.Now())
+
+	// Save any silences that were added before the store was set
+	return store.Save(s.addedList())
+}
+
+// Add checks a silence and adds it with a new ID
+func (s *Silences) Add(silence *Silence) error {
+	err := silence.Check()
+	if err != nil {
+		return err
+	}
+
+	id := make([]byte, 8)
+	_, err = rand.Read(id)
+	if err != nil {
+		return err
+	}
+	silence.ID = hex.EncodeToString(id)
+	silence.Source = "api"
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prune(time.Now())
+	s.added[silence.ID] = silence
+	s.save()
+	return nil
+}
+
+// ErrStaticSilence is returned when removing a silence that is in the config
+var ErrStaticSilence = fmt.Errorf("Silence is set in the config")
+
+// Remove expires a silence that was added through the API, returning whether
+// it was found
+func (s *Silences) Remove(id string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, silence := range s.static {
+		if silence.ID == id {
+			return true, ErrStaticSilence
+		}
+	}
+	if _, ok := s.added[id]; !ok {
+		return false, nil
+	}
+	delete(s.added, id)
+	s.save()
+	return true, nil
+}
+
+// List returns the silences that have not ended, sorted by when they end
+func (s *Silences) List() []Silence {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.prune(now)
+
+	silences := make([]Silence, 0, len(s.static)+len(s.added))
+	for _, silence := range s.all() {
+		if now.Before(silence.EndsAt) {
+			silences = append(silences, *silence)
+		}
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].EndsAt.Before(silences[j].EndsAt)
+	})
+	return silences
+}
+
+// Silenced returns the IDs of the silences that apply to the alert's job at t
+func (s *Silences) Silenced(alert Alert, t time.Time) []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	labels := alertLabels(alert)
+	ids := make([]string, 0)
+	for _, silence := range s.all() {
+		if silence.active(t) && silence.matches(labels) {
+			ids = append(ids, silence.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Silences) all() []*Silence {
+	silences := make([]*Silence, 0, len(s.static)+len(s.added))
+	silences = append(silences, s.static...)
+	return append(silences, s.addedList()...)
+}
+
+func (s *Silences) addedList() []*Silence {
+	silences := make([]*Silence, 0, len(s.added))
+	for _, silence := range s.added {
+		silences = append(silences, silence)
+	}
+	return silences
+}
+
+// save writes the silences added through the API to the store. They are
+// still used if they can't be saved, but will be lost on restart.
+func (s *Silences) save() {
+	if s.store == nil {
+		return
+	}
+	err := s.store.Save(s.addedList())
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not store silences")
+	}
+}
+
+// prune forgets the silences added through the API that have ended
+func (s *Silences) prune(now time.Time) {
+	for id, silence := range s.added {
+		if !now.Before(silence.EndsAt) {
+			delete(s.added, id)
+		}
+	}
+}
+
+// MaintenanceWindow silences a job for Duration from each time in its cron
+// Schedule, e.g. "0 2 * * SUN" for 2am every Sunday
+type MaintenanceWindow struct {
+	Schedule *string
+	Duration *time.Duration
+	Comment  *string
+	schedule cron.Schedule
+}
+
+func (m *MaintenanceWindow) Check() error {
+	if m.Schedule == nil {
+		return fmt.Errorf("MaintenanceWindow.Schedule cannot be empty")
+	}
+	schedule, err := cron.ParseStandard(*m.Schedule)
+	if err != nil {
+		return fmt.Errorf("MaintenanceWindow.Schedule Error: %w", err)
+	}
+	m.schedule = schedule
+
+	if m.Duration == nil || *m.Duration <= 0 {
+		return fmt.Errorf("MaintenanceWindow.Duration must be positive")
+	}
+	if m.Comment == nil {
+		comment := "Scheduled maintenance"
+		m.Comment = &comment
+	}
+	return nil
+}
+
+// active returns whether the window started within Duration of t
+func (m *MaintenanceWindow) active(t time.Time) bool {
+	start := m.schedule.Next(t.Add(-*m.Duration))
+	return !start.After(t)
+}
+
+// maintenance returns the comment of the job's active maintenance window, or
+// an empty string if there isn't one
+func maintenance(windows []*MaintenanceWindow, t time.Time) string {
+	for _, w := range windows {
+		if w.active(t) {
+			return *w.Comment
+		}
+	}
+	return ""
+}
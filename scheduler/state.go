@@ -1,5 +1,10 @@
 package scheduler
 
+import (
+	"fmt"
+	"strings"
+)
+
 type State int
 
 const (
@@ -12,3 +17,14 @@ const (
 func (s State) String() string {
 	return [...]string{"Ok", "Pending", "Alerting", "No_Data"}[s]
 }
+
+// parseState returns the State with the given name, ignoring case so that the
+// config can use e.g. alerting or no_data
+func parseState(name string) (State, error) {
+	for _, s := range []State{OkState, PendingState, AlertingState, NoDataState} {
+		if strings.EqualFold(s.String(), name) {
+			return s, nil
+		}
+	}
+	return NoDataState, fmt.Errorf("State '%s' isn't valid", name)
+}
@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// JobStatus is the current status of a Job as published after every run
+type JobStatus struct {
+	Name        string                `json:"name"`
+	State       string                `json:"state"`
+	Down        bool                  `json:"down"`
+	Since       time.Time             `json:"since"`
+	TimeInState float64               `json:"time_in_state"`
+	LastRun     time.Time             `json:"last_run"`
+	Duration    float64               `json:"duration"`
+	LastError   string                `json:"last_error,omitempty"`
+	Tests       map[string]TestStatus `json:"tests"`
+	Values      map[string]string     `json:"values,omitempty"`
+	Labels      map[string]string     `json:"labels,omitempty"`
+	Silenced    bool                  `json:"silenced"`
+	Silences    []string              `json:"silences,omitempty"`
+	Maintenance string                `json:"maintenance,omitempty"`
+	Transitions []TransitionStatus    `json:"transitions"`
+	windows     []*MaintenanceWindow
+}
+
+// TransitionStatus records why a Job changed State
+type TransitionStatus struct {
+	Time       time.Time                    `json:"time"`
+	From       string                       `json:"from"`
+	To         string                       `json:"to"`
+	Expression string                       `json:"expression"`
+	Failed     []string                     `json:"failed"`
+	Reason     string                       `json:"reason"`
+	Errors     map[string]string            `json:"errors,omitempty"`
+	Values     map[string]map[string]string `json:"values,omitempty"`
+}
+
+// maxTransitions is the number of Transitions kept for each Job
+const maxTransitions = 20
+
+// TestStatus is the result of the last run of a Test
+type TestStatus struct {
+	State      string            `json:"state"`
+	StatusCode int               `json:"status_code"`
+	Duration   float64           `json:"duration"`
+	Error      string            `json:"error,omitempty"`
+	Values     map[string]string `json:"values,omitempty"`
+}
+
+// AlerterStatus is the current status of an Alerter
+type AlerterStatus struct {
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"`
+	Default    bool              `json:"default"`
+	AlwaysSend bool              `json:"always_send"`
+	LastSent   *time.Time        `json:"last_sent,omitempty"`
+	LastError  string            `json:"last_error,omitempty"`
+	Jobs       map[string]string `json:"jobs"`
+}
+
+// Status is a Listener that keeps the latest status of every Job and Alerter
+type Status struct {
+	mutex    sync.RWMutex
+	jobs     map[string]*JobStatus
+	alerters map[string]*AlerterStatus
+	silences *Silences
+}
+
+func NewStatus(alerters map[string]*Alerter, silences *Silences) *Status {
+	s := &Status{
+		jobs:     make(map[string]*JobStatus),
+		alerters: make(map[string]*AlerterStatus, len(alerters)),
+		silences: silences,
+	}
+
+	for n, a := range alerters {
+		s.alerters[n] = &AlerterStatus{
+			Name:       n,
+			Kind:       a.kind,
+			Default:    *a.Default,
+			AlwaysSend: *a.AlwaysSend,
+			Jobs:       make(map[string]string),
+		}
+	}
+
+	return s
+}
+
+func (s *Status) JobFinished(name string, job *Job, result JobResult) {
+	status := &JobStatus{
+		Name:     name,
+		State:    result.State.String(),
+		Down:     result.Down(),
+		Since:    result.Since,
+		LastRun:  result.Time,
+		Duration: result.Duration.Seconds(),
+		Tests:    make(map[string]TestStatus, len(result.Tests)),
+		Values:   job.Values,
+		Labels:   job.Labels,
+		windows:  job.Maintenance,
+	}
+	if result.Err != nil {
+		status.LastError = result.Err.Error()
+	}
+
+	for n, t := range result.Tests {
+		test := TestStatus{
+			State:      t.State.String(),
+			StatusCode: t.StatusCode,
+			Duration:   t.Duration.Seconds(),
+			Values:     t.Values,
+		}
+		if t.Err != nil {
+			test.Error = t.Err.Error()
+		}
+		status.Tests[n] = test
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if prev, ok := s.jobs[name]; ok {
+		status.Transitions = prev.Transitions
+	} else {
+		status.Transitions = make([]TransitionStatus, 0)
+	}
+	if t := result.Transition; t != nil && result.State != result.PrevState {
+		transitions := append([]TransitionStatus{{
+			Time:       t.Time,
+			From:       t.From.String(),
+			To:         t.To.String(),
+			Expression: t.Expression,
+			Failed:     t.Failed,
+			Reason:     t.Reason(),
+			Errors:     t.Errors,
+			Values:     t.Values,
+		}}, status.Transitions...)
+		if len(transitions) > maxTransitions {
+			transitions = transitions[:maxTransitions]
+		}
+		status.Transitions = transitions
+	}
+
+	s.jobs[name] = status
+}
+
+func (s *Status) JobStopped(name string) {
+	s.mutex.Lock()
+	delete(s.jobs, name)
+	s.mutex.Unlock()
+}
+
+func (s *Status) AlertSent(alerter string, alert Alert, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a, ok := s.alerters[alerter]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	a.LastSent = &now
+	a.LastError = ""
+	if err != nil {
+		a.LastError = err.Error()
+	}
+	a.Jobs[alert.Job] = alert.State.String()
+}
+
+// Jobs returns the status of every Job sorted by name
+func (s *Status) Jobs() []JobStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	jobs := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, s.jobStatus(j))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+
+	return jobs
+}
+
+// Job returns the status of the named Job
+func (s *Status) Job(name string) (JobStatus, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return JobStatus{}, false
+	}
+	return s.jobStatus(j), true
+}
+
+// Alerters returns the status of every Alerter sorted by name
+func (s *Status) Alerters() []AlerterStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	alerters := make([]AlerterStatus, 0, len(s.alerters))
+	for _, a := range s.alerters {
+		alerter := *a
+		alerter.Jobs = make(map[string]string, len(a.Jobs))
+		for k, v := range a.Jobs {
+			alerter.Jobs[k] = v
+		}
+		alerters = append(alerters, alerter)
+	}
+	sort.Slice(alerters, func(i, j int) bool {
+		return alerters[i].Name < alerters[j].Name
+	})
+
+	return alerters
+}
+
+// jobStatus copies a JobStatus, calculating the time spent in its state and
+// whether it is silenced
+func (s *Status) jobStatus(j *JobStatus) JobStatus {
+	now := time.Now()
+	status := *j
+	status.TimeInState = now.Sub(j.Since).Seconds()
+
+	status.Silences = s.silences.Silenced(Alert{Job: j.Name, Labels: j.Labels}, now)
+	status.Maintenance = maintenance(j.windows, now)
+	status.Silenced = len(status.Silences) > 0 || status.Maintenance != ""
+	return status
+}
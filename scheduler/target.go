@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Target is a set of variables used to expand a single Job definition into a
+// concrete Job. Each field is available as a template variable in requests
+// and is added to the Values sent with alerts.
+type Target map[string]string
+
+// Name returns the name used to identify the Target. A "name" field is used
+// in place of the other fields if present, followed by the fields set by the
+// matrix so that every combination has its own name. Otherwise the sorted
+// key=value pairs are joined together.
+func (t Target) Name(matrix map[string][]string) string {
+	name, named := t["name"]
+	if _, ok := matrix["name"]; ok {
+		named = false
+	}
+
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		if _, ok := matrix[k]; named && !ok {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys)+1)
+	if named {
+		pairs = append(pairs, name)
+	}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, t[k]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// JobName returns the name of the Job created by expanding the named Job with
+// this Target and the matrix it was combined with.
+func (t Target) JobName(job string, matrix map[string][]string) string {
+	return fmt.Sprintf("%s[%s]", job, t.Name(matrix))
+}
+
+// expandMatrix returns the cartesian product of the given targets and every
+// value in the matrix. There are none without any targets.
+func expandMatrix(targets []Target, matrix map[string][]string) []Target {
+	keys := make([]string, 0, len(matrix))
+	for k := range matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		expanded := make([]Target, 0, len(targets)*len(matrix[k]))
+		for _, t := range targets {
+			for _, v := range matrix[k] {
+				target := make(Target, len(t)+1)
+				for tk, tv := range t {
+					target[tk] = tv
+				}
+				target[k] = v
+				expanded = append(expanded, target)
+			}
+		}
+		targets = expanded
+	}
+
+	return targets
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"testing"
+)
+
+func TestTargetJobName(t *testing.T) {
+	matrix := map[string][]string{"port": {"8080", "8443"}}
+	targets := []Target{
+		{"name": "eu", "host": "eu.example.com"},
+		{"name": "us", "host": "us.example.com"},
+	}
+
+	tests := []struct {
+		targets []Target
+		matrix  map[string][]string
+		want    []string
+	}{
+		{targets, nil, []string{"api[eu]", "api[us]"}},
+		{targets, matrix, []string{"api[eu,port=8080]", "api[eu,port=8443]", "api[us,port=8080]", "api[us,port=8443]"}},
+		{[]Target{{"host": "a"}}, matrix, []string{"api[host=a,port=8080]", "api[host=a,port=8443]"}},
+		{[]Target{{}}, map[string][]string{"name": {"x"}}, []string{"api[name=x]"}},
+		// Nothing discovered starts no jobs
+		{nil, matrix, nil},
+	}
+	for _, tt := range tests {
+		expanded := tt.targets
+		if tt.matrix != nil {
+			expanded = expandMatrix(tt.targets, tt.matrix)
+		}
+		if len(expanded) != len(tt.want) {
+			t.Fatalf("expanded %d targets, want %d", len(expanded), len(tt.want))
+		}
+		for i, target := range expanded {
+			if got := target.JobName("api", tt.matrix); got != tt.want[i] {
+				t.Errorf("JobName() = %q, want %q", got, tt.want[i])
+			}
+		}
+	}
+}
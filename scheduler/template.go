@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs returns the functions available to every template
+func templateFuncs(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(name string) (string, error) {
+			secrets, ok := ctx.Value("secrets").(Secrets)
+			if !ok {
+				return "", fmt.Errorf("Secrets are not available here")
+			}
+			return secrets.Get(name)
+		},
+
+		"toJson":  toJSON,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"replace": replace,
+		"join":    join,
+		"default": defaultValue,
+		"ternary": ternary,
+
+		"now":           time.Now,
+		"formatTime":    formatTime,
+		"durationSince": durationSince,
+
+		"stateColor":        stateColor,
+		"stateColorDecimal": stateColorDecimal,
+
+		"isOk":       isState(OkState),
+		"isPending":  isState(PendingState),
+		"isAlerting": isState(AlertingState),
+		"isNoData":   isState(NoDataState),
+	}
+}
+
+// toJSON encodes the value as JSON, so a string is quoted and escaped
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// join joins the items of a list with sep
+func join(sep string, list interface{}) (string, error) {
+	v := reflect.ValueOf(list)
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T isn't a list", list)
+	}
+
+	items := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items = append(items, fmt.Sprint(v.Index(i).Interface()))
+	}
+	return strings.Join(items, sep), nil
+}
+
+// defaultValue returns def if the value is empty, e.g. {{.value | default "n/a"}}
+func defaultValue(def interface{}, value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.IsZero() {
+		return def
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return def
+	}
+	return value
+}
+
+// ternary returns a if cond is true, otherwise b
+func ternary(a, b interface{}, cond bool) interface{} {
+	if cond {
+		return a
+	}
+	return b
+}
+
+// toTime accepts a time.Time or an RFC3339 string, such as .since
+func toTime(t interface{}) (time.Time, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339, v)
+	}
+	return time.Time{}, fmt.Errorf("%T isn't a time", t)
+}
+
+// formatTime formats the time using a Go layout, e.g. "2006-01-02 15:04"
+func formatTime(layout string, t interface{}) (string, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return "", err
+	}
+	return tm.Format(layout), nil
+}
+
+// durationSince returns the time since t rounded to the second, e.g. "5m30s"
+func durationSince(t interface{}) (string, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return "", err
+	}
+	return time.Since(tm).Round(time.Second).String(), nil
+}
+
+// isState returns a function that checks whether a state, such as .state, is
+// the given State
+func isState(s State) func(interface{}) bool {
+	return func(state interface{}) bool {
+		switch v := state.(type) {
+		case State:
+			return v == s
+		case string:
+			return v == s.String()
+		}
+		return false
+	}
+}
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"isup/testparser"
 )
@@ -42,23 +43,52 @@ func (t *Test) Check() error {
 	return t.Response.Check()
 }
 
-func (t *Test) Run(ctx context.Context) (State, error) {
+// TestResult contains the details of a single run of a Test
+type TestResult struct {
+	State      State
+	StatusCode int
+	Duration   time.Duration
+	Values     map[string]string
+	Err        error
+}
+
+func (t *Test) Run(ctx context.Context, vars Replacement) (TestResult, error) {
 	rep := Replacement{}
 	rep = rep.WithEnv()
+	for k, v := range vars {
+		rep[k] = v
+	}
+
+	result := TestResult{
+		State: NoDataState,
+	}
+
+	start := time.Now()
 	resp, err := t.Request.Run(ctx, &rep)
+	result.Duration = time.Since(start)
 	if err != nil {
-		return NoDataState, err
+		return result, err
 	}
 	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
 
 	res, err := t.Response.Run(ctx, resp)
 	if err != nil {
-		return NoDataState, err
+		return result, err
+	}
+
+	result.Values = make(map[string]string, len(*res))
+	for n, v := range *res {
+		if n != "status_code" {
+			result.Values[n] = v.StrValue
+		}
 	}
 
 	ok, err := t.ok.Evaluate(res)
 	if ok {
-		return OkState, nil
+		result.State = OkState
+		return result, nil
 	}
-	return AlertingState, err
+	result.State = AlertingState
+	return result, err
 }
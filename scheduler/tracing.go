@@ -0,0 +1,12 @@
+package scheduler
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer returns the Tracer used for every span. It is looked up each time as
+// the TracerProvider is replaced when the config is reloaded.
+func tracer() trace.Tracer {
+	return otel.Tracer("isup/scheduler")
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transition records why a Job changed from one State to another
+type Transition struct {
+	Time       time.Time
+	From       State
+	To         State
+	Expression string
+	Failed     []string
+	Errors     map[string]string
+	Values     map[string]map[string]string
+	Err        error
+}
+
+func newTransition(expression string, from, to State, t time.Time, result JobResult) *Transition {
+	tr := &Transition{
+		Time:       t,
+		From:       from,
+		To:         to,
+		Expression: expression,
+		Failed:     make([]string, 0),
+		Errors:     make(map[string]string),
+		Values:     make(map[string]map[string]string, len(result.Tests)),
+		Err:        result.Err,
+	}
+
+	for n, test := range result.Tests {
+		if test.State != OkState {
+			tr.Failed = append(tr.Failed, n)
+		}
+		if test.Err != nil {
+			tr.Errors[n] = test.Err.Error()
+		}
+		if len(test.Values) > 0 {
+			tr.Values[n] = test.Values
+		}
+	}
+	sort.Strings(tr.Failed)
+
+	return tr
+}
+
+// Reason describes the failed Tests and their errors
+func (t *Transition) Reason() string {
+	if len(t.Failed) == 0 {
+		if t.Err != nil {
+			return t.Err.Error()
+		}
+		return fmt.Sprintf("%s passed", t.Expression)
+	}
+
+	reasons := make([]string, 0, len(t.Failed))
+	for _, n := range t.Failed {
+		if err, ok := t.Errors[n]; ok {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", n, err))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%s: failed", n))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
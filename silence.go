@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"isup/scheduler"
+)
+
+// parseMatchers parses matchers given as NAME=VALUE
+func parseMatchers(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	matchers := make(map[string]string, len(values))
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Invalid matcher '%s', expected NAME=VALUE", v)
+		}
+		matchers[kv[0]] = kv[1]
+	}
+	return matchers, nil
+}
+
+// silencesURL returns the URL of the silences API, or of the silence with id
+func silencesURL(c *cli.Context, id string) string {
+	u := strings.TrimSuffix(c.String("url"), "/") + "/api/silences"
+	if id != "" {
+		u += "/" + url.PathEscape(id)
+	}
+	return u
+}
+
+// apiRequest sends a request to the API, with the token if one is set
+func apiRequest(c *cli.Context, method, u string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.String("token"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// apiError returns the error in the response of the API
+func apiError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != "" {
+		return fmt.Errorf("%s (%d)", body.Error, resp.StatusCode)
+	}
+	return fmt.Errorf("Unexpected status code %d", resp.StatusCode)
+}
+
+func addSilence(c *cli.Context) error {
+	match, err := parseMatchers(c.StringSlice("match"))
+	if err != nil {
+		return err
+	}
+	matchRE, err := parseMatchers(c.StringSlice("match-re"))
+	if err != nil {
+		return err
+	}
+	if jobs := c.StringSlice("job"); len(jobs) > 0 {
+		if matchRE == nil {
+			matchRE = make(map[string]string)
+		}
+		quoted := make([]string, 0, len(jobs))
+		for _, j := range jobs {
+			quoted = append(quoted, regexp.QuoteMeta(j))
+		}
+		matchRE["job"] = strings.Join(quoted, "|")
+	}
+
+	now := time.Now()
+	start, err := parseStart(c.String("start"), now)
+	if err != nil {
+		return fmt.Errorf("Invalid --start: %w", err)
+	}
+
+	silence := scheduler.Silence{
+		Matchers: scheduler.Matchers{
+			Match:   match,
+			MatchRE: matchRE,
+		},
+		StartsAt:  start,
+		EndsAt:    start.Add(c.Duration("duration")),
+		Comment:   c.String("comment"),
+		CreatedBy: c.String("author"),
+	}
+	body, err := json.Marshal(silence)
+	if err != nil {
+		return err
+	}
+
+	resp, err := apiRequest(c, http.MethodPost, silencesURL(c, ""), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return apiError(resp)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&silence)
+	if err != nil {
+		return err
+	}
+	fmt.Println(silence.ID)
+	return nil
+}
+
+// parseStart parses an RFC3339 time, or a duration after now
+func parseStart(value string, now time.Time) (time.Time, error) {
+	if value == "" || value == "now" {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func listSilences(c *cli.Context) error {
+	resp, err := apiRequest(c, http.MethodGet, silencesURL(c, ""), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+
+	var silences []scheduler.Silence
+	err = json.NewDecoder(resp.Body).Decode(&silences)
+	if err != nil {
+		return err
+	}
+	return writeSilencesTable(os.Stdout, silences)
+}
+
+func writeSilencesTable(w io.Writer, silences []scheduler.Silence) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tMATCHERS\tSTARTS\tENDS\tCREATED BY\tCOMMENT")
+	for _, s := range silences {
+		starts := "-"
+		if !s.StartsAt.IsZero() {
+			starts = s.StartsAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.ID,
+			formatMatchers(s.Matchers),
+			starts,
+			s.EndsAt.Local().Format(time.RFC3339),
+			s.CreatedBy,
+			s.Comment,
+		)
+	}
+	return tw.Flush()
+}
+
+// formatMatchers formats matchers as a sorted list of NAME=VALUE and
+// NAME=~VALUE
+func formatMatchers(m scheduler.Matchers) string {
+	matchers := make([]string, 0, len(m.Match)+len(m.MatchRE))
+	for k, v := range m.Match {
+		matchers = append(matchers, k+"="+v)
+	}
+	for k, v := range m.MatchRE {
+		matchers = append(matchers, k+"=~"+v)
+	}
+	sort.Strings(matchers)
+	return strings.Join(matchers, ",")
+}
+
+func expireSilence(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("Expected the ID of the silence to expire")
+	}
+
+	resp, err := apiRequest(c, http.MethodDelete, silencesURL(c, c.Args().First()), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return apiError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package statuspage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatusPage configures the HTML status page
+type StatusPage struct {
+	Title      *string
+	Components []*Component
+	Export     *Export
+}
+
+// Component groups Jobs together on the status page
+type Component struct {
+	Name        *string
+	Description string
+	Jobs        []string
+}
+
+// Export periodically writes the status page to a file
+type Export struct {
+	File     *string
+	Interval *time.Duration
+}
+
+func (s *StatusPage) Check() error {
+	if s.Title == nil {
+		title := "Status"
+		s.Title = &title
+	}
+
+	for i, c := range s.Components {
+		if c.Name == nil {
+			return fmt.Errorf("StatusPage.Components[%d].Name cannot be empty", i)
+		}
+		if len(c.Jobs) == 0 {
+			return fmt.Errorf("StatusPage.Components '%s' must have jobs", *c.Name)
+		}
+	}
+
+	if s.Export != nil {
+		if s.Export.File == nil {
+			return fmt.Errorf("StatusPage.Export.File cannot be empty")
+		}
+		if s.Export.Interval == nil {
+			interval := time.Minute
+			s.Export.Interval = &interval
+		}
+	}
+
+	return nil
+}
+
+// Matches returns whether the Job belongs to the Component. A Job matches if
+// its name is listed, or if it was expanded from a listed Job.
+func (c *Component) Matches(job string) bool {
+	for _, j := range c.Jobs {
+		if job == j || strings.HasPrefix(job, j+"[") {
+			return true
+		}
+	}
+	return false
+}
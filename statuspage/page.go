@@ -0,0 +1,319 @@
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"isup/redact"
+	"isup/scheduler"
+)
+
+// Page renders the status page from the status published by the scheduler and
+// the Uptime of every Job
+type Page struct {
+	mutex  sync.RWMutex
+	config *StatusPage
+	status *scheduler.Status
+	uptime *Uptime
+}
+
+type pageData struct {
+	Title       string
+	Updated     time.Time
+	State       string
+	Incidents   []incidentData
+	Maintenance []maintenanceData
+	Components  []componentData
+}
+
+type componentData struct {
+	Name        string
+	Description string
+	State       string
+	Uptime      string
+	Days        []dayData
+	Jobs        []scheduler.JobStatus
+	Maintenance bool
+}
+
+type dayData struct {
+	Class string
+	Title string
+}
+
+type incidentData struct {
+	Component string
+	Job       string
+	Since     time.Time
+	Error     string
+	Silenced  bool
+}
+
+// maintenanceData is a job that is silenced or in a maintenance window
+type maintenanceData struct {
+	Component string
+	Job       string
+	Comment   string
+}
+
+// Jobs in worse states are shown in place of the others in their component
+var severity = map[string]int{
+	scheduler.OkState.String():       0,
+	scheduler.NoDataState.String():   1,
+	scheduler.PendingState.String():  2,
+	scheduler.AlertingState.String(): 3,
+}
+
+func NewPage(uptime *Uptime) *Page {
+	return &Page{
+		uptime: uptime,
+	}
+}
+
+// Set replaces the config and Status used to render the page, such as after a
+// reload
+func (p *Page) Set(config *StatusPage, status *scheduler.Status) {
+	p.mutex.Lock()
+	p.config = config
+	p.status = status
+	p.mutex.Unlock()
+}
+
+func (p *Page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := p.Render(w)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not render status page")
+	}
+}
+
+// Render writes the status page as HTML
+func (p *Page) Render(w io.Writer) error {
+	var buf bytes.Buffer
+	err := pageTemplate.Execute(&buf, p.data(time.Now()))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(redact.Bytes(buf.Bytes()))
+	return err
+}
+
+// Export writes the status page to the configured file on an interval until
+// the context is done
+func (p *Page) Export(ctx context.Context) {
+	p.mutex.RLock()
+	export := p.config.Export
+	p.mutex.RUnlock()
+	if export == nil {
+		return
+	}
+
+	for {
+		err := p.export(*export.File)
+		if err != nil {
+			log.Warn().
+				Str("filename", *export.File).
+				Err(err).
+				Msg("Could not export status page")
+		} else {
+			log.Debug().
+				Str("filename", *export.File).
+				Msg("Status page exported")
+		}
+
+		select {
+		case <-time.After(*export.Interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Page) export(filename string) error {
+	// Write to a temporary file first so the page is never half written
+	f, err := ioutil.TempFile(filepath.Dir(filename), ".isup-status-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = p.Render(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(f.Name(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
+
+func (p *Page) data(now time.Time) pageData {
+	p.mutex.RLock()
+	config := p.config
+	status := p.status
+	p.mutex.RUnlock()
+
+	data := pageData{
+		Title:   *config.Title,
+		Updated: now,
+		State:   scheduler.OkState.String(),
+	}
+
+	var jobs []scheduler.JobStatus
+	if status != nil {
+		jobs = status.Jobs()
+	}
+	uptimeJobs := p.uptime.jobNames()
+
+	components := config.Components
+	if len(components) == 0 {
+		// Without components every job is shown on its own
+		for _, j := range jobs {
+			name := j.Name
+			components = append(components, &Component{
+				Name: &name,
+				Jobs: []string{j.Name},
+			})
+		}
+	}
+
+	for _, c := range components {
+		component := componentData{
+			Name:        *c.Name,
+			Description: c.Description,
+			State:       scheduler.OkState.String(),
+		}
+
+		for _, j := range jobs {
+			if !c.Matches(j.Name) {
+				continue
+			}
+			component.Jobs = append(component.Jobs, j)
+			// A job that can't be reached is down, as in the uptime
+			state := j.State
+			if j.Down {
+				state = scheduler.AlertingState.String()
+			}
+			if severity[state] > severity[component.State] {
+				component.State = state
+			}
+			if j.Down {
+				data.Incidents = append(data.Incidents, incidentData{
+					Component: *c.Name,
+					Job:       j.Name,
+					Since:     j.Since,
+					Error:     jobError(j),
+					Silenced:  j.Silenced,
+				})
+			}
+			if j.Silenced {
+				component.Maintenance = true
+				comment := j.Maintenance
+				if comment == "" {
+					comment = "Under maintenance"
+				}
+				data.Maintenance = append(data.Maintenance, maintenanceData{
+					Component: *c.Name,
+					Job:       j.Name,
+					Comment:   comment,
+				})
+			}
+		}
+		if len(component.Jobs) == 0 {
+			component.State = scheduler.NoDataState.String()
+		}
+		if severity[component.State] > severity[data.State] {
+			data.State = component.State
+		}
+
+		matched := make([]string, 0)
+		for _, j := range uptimeJobs {
+			if c.Matches(j) {
+				matched = append(matched, j)
+			}
+		}
+		component.Uptime, component.Days = uptimeData(p.uptime.days(matched, now), now)
+
+		data.Components = append(data.Components, component)
+	}
+
+	sort.Slice(data.Incidents, func(i, j int) bool {
+		return data.Incidents[i].Since.Before(data.Incidents[j].Since)
+	})
+
+	return data
+}
+
+// jobError returns the error of the job, or of its first test that failed to
+// run if the job has no data
+func jobError(j scheduler.JobStatus) string {
+	if j.LastError != "" {
+		return j.LastError
+	}
+	names := make([]string, 0, len(j.Tests))
+	for n := range j.Tests {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := j.Tests[n].Error; err != "" {
+			return err
+		}
+	}
+	return ""
+}
+
+// uptimeData returns the overall uptime and the bar for each day
+func uptimeData(counts []dayCount, now time.Time) (string, []dayData) {
+	days := make([]dayData, len(counts))
+	up, total := 0, 0
+
+	for i, c := range counts {
+		date := day(now).AddDate(0, 0, i-len(counts)+1).Format("2006-01-02")
+		up += c.Up
+		total += c.Total
+
+		if c.Total == 0 {
+			days[i] = dayData{
+				Class: "none",
+				Title: fmt.Sprintf("%s: No data", date),
+			}
+			continue
+		}
+
+		ratio := float64(c.Up) / float64(c.Total)
+		class := "up"
+		if ratio < 0.95 {
+			class = "down"
+		} else if ratio < 1 {
+			class = "degraded"
+		}
+		days[i] = dayData{
+			Class: class,
+			Title: fmt.Sprintf("%s: %.2f%%", date, ratio*100),
+		}
+	}
+
+	if total == 0 {
+		return "No data", days
+	}
+	return fmt.Sprintf("%.2f%%", float64(up)/float64(total)*100), days
+}
@@ -0,0 +1,67 @@
+package statuspage
+
+import (
+	"html/template"
+)
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; background: #f7f7f7; margin: 0; }
+main { max-width: 52rem; margin: 0 auto; padding: 2rem 1rem; }
+h1 { font-size: 1.8rem; }
+h2 { font-size: 1.2rem; margin-top: 2rem; }
+.banner { padding: 1rem; border-radius: 4px; color: #fff; font-weight: bold; }
+.component, .incident { background: #fff; border: 1px solid #ddd; border-radius: 4px; padding: 1rem; margin-bottom: 0.5rem; }
+.component header { display: flex; justify-content: space-between; }
+.description { color: #666; font-size: 0.9rem; }
+.maintenance { color: #3867d6; font-size: 0.9rem; margin-right: 0.5rem; }
+.state { font-weight: bold; }
+.Ok { color: #2e9e44; } .banner.Ok { background: #2e9e44; }
+.Pending { color: #e0a800; } .banner.Pending { background: #e0a800; }
+.Alerting { color: #d33; } .banner.Alerting { background: #d33; }
+.No_Data { color: #888; } .banner.No_Data { background: #888; }
+.bars { display: flex; gap: 1px; height: 2rem; margin: 0.5rem 0 0.25rem; }
+.bars span { flex: 1; border-radius: 1px; }
+.bars .up { background: #2e9e44; } .bars .degraded { background: #e0a800; } .bars .down { background: #d33; } .bars .none { background: #ddd; }
+.legend { display: flex; justify-content: space-between; color: #888; font-size: 0.8rem; }
+footer { color: #888; font-size: 0.8rem; margin-top: 2rem; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+{{if eq .State "Ok"}}<div class="banner Ok">All systems operational</div>
+{{else if eq .State "Alerting"}}<div class="banner Alerting">Some systems are down</div>
+{{else if eq .State "Pending"}}<div class="banner Pending">Some systems are degraded</div>
+{{else}}<div class="banner No_Data">Some systems have no data</div>
+{{end}}
+{{if .Incidents}}<h2>Active incidents</h2>
+{{range .Incidents}}<div class="incident">
+<div><span class="state Alerting">{{.Component}}</span> &ndash; {{.Job}}</div>
+<div class="description">Since {{.Since.UTC.Format "2006-01-02 15:04 MST"}}{{if .Error}}: {{.Error}}{{end}}{{if .Silenced}} (under maintenance){{end}}</div>
+</div>
+{{end}}{{end}}
+{{if .Maintenance}}<h2>Maintenance</h2>
+{{range .Maintenance}}<div class="incident">
+<div><span class="maintenance">{{.Component}}</span> &ndash; {{.Job}}</div>
+<div class="description">{{.Comment}}</div>
+</div>
+{{end}}{{end}}
+<h2>Components</h2>
+{{range .Components}}<div class="component">
+<header><span>{{.Name}}</span><span>{{if .Maintenance}}<span class="maintenance">Maintenance</span>{{end}}<span class="state {{.State}}">{{.State}}</span></span></header>
+{{if .Description}}<div class="description">{{.Description}}</div>{{end}}
+<div class="bars">{{range .Days}}<span class="{{.Class}}" title="{{.Title}}"></span>{{end}}</div>
+<div class="legend"><span>90 days ago</span><span>{{.Uptime}} uptime</span><span>Today</span></div>
+</div>
+{{end}}
+<footer>Updated {{.Updated.UTC.Format "2006-01-02 15:04:05 MST"}}</footer>
+</main>
+</body>
+</html>
+`))
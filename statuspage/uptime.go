@@ -0,0 +1,133 @@
+package statuspage
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"isup/history"
+	"isup/scheduler"
+)
+
+// Days is the number of days of uptime that are kept and shown
+const Days = 90
+
+type dayCount struct {
+	Up    int
+	Total int
+}
+
+// Uptime is a Listener that counts the runs of every Job that were up on each
+// day. A run is up unless the Job is down, in the same way as in reports, and
+// runs without data that aren't down are not counted.
+type Uptime struct {
+	mutex sync.RWMutex
+	jobs  map[string]map[time.Time]*dayCount
+}
+
+func NewUptime() *Uptime {
+	return &Uptime{
+		jobs: make(map[string]map[time.Time]*dayCount),
+	}
+}
+
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func (u *Uptime) JobFinished(name string, job *scheduler.Job, result scheduler.JobResult) {
+	if result.State == scheduler.NoDataState && !result.Down() {
+		return
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.count(name, result.Time, !result.Down())
+}
+
+// Load counts the results in the history from the days that are shown, so that
+// the uptime isn't lost when isup restarts. It should be called once before
+// any Jobs run.
+func (u *Uptime) Load(path string, now time.Time) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	from := day(now).AddDate(0, 0, -Days+1)
+	return history.Walk(path, from, now, func(r history.JobRecord) error {
+		if r.State == scheduler.NoDataState.String() && !r.Down() {
+			return nil
+		}
+		u.count(r.Job, r.Time, !r.Down())
+		return nil
+	})
+}
+
+// count adds a run of the Job at t
+func (u *Uptime) count(name string, t time.Time, up bool) {
+	days, ok := u.jobs[name]
+	if !ok {
+		days = make(map[time.Time]*dayCount)
+		u.jobs[name] = days
+	}
+
+	d := day(t)
+	count, ok := days[d]
+	if !ok {
+		count = &dayCount{}
+		days[d] = count
+
+		// Forget the days that are no longer shown
+		oldest := d.AddDate(0, 0, -Days)
+		for t := range days {
+			if !t.After(oldest) {
+				delete(days, t)
+			}
+		}
+	}
+
+	count.Total += 1
+	if up {
+		count.Up += 1
+	}
+}
+
+// JobStopped keeps the uptime of the Job as it may be started again
+func (u *Uptime) JobStopped(name string) {
+}
+
+// days returns the number of up and total runs on each of the last Days days,
+// oldest first, for all the given Jobs
+func (u *Uptime) days(jobs []string, now time.Time) []dayCount {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	counts := make([]dayCount, Days)
+	today := day(now)
+	for i := range counts {
+		d := today.AddDate(0, 0, i-Days+1)
+		for _, j := range jobs {
+			if count, ok := u.jobs[j][d]; ok {
+				counts[i].Up += count.Up
+				counts[i].Total += count.Total
+			}
+		}
+	}
+
+	return counts
+}
+
+// jobNames returns the names of every Job with uptime
+func (u *Uptime) jobNames() []string {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	jobs := make([]string, 0, len(u.jobs))
+	for j := range u.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
@@ -211,7 +211,7 @@ var g = &grammar{
 									label: "comparator",
 									expr: &ruleRefExpr{
 										pos:  position{line: 45, col: 38, offset: 1002},
-										name: "NumComparator",
+										name: "StrComparator",
 									},
 								},
 								&ruleRefExpr{
@@ -223,6 +223,51 @@ var g = &grammar{
 									label: "value",
 									expr: &ruleRefExpr{
 										pos:  position{line: 45, col: 60, offset: 1024},
+										name: "Bool",
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 52, col: 5, offset: 1209},
+						run: (*parser).callonComparison24,
+						expr: &seqExpr{
+							pos: position{line: 52, col: 5, offset: 1209},
+							exprs: []interface{}{
+								&ruleRefExpr{
+									pos:  position{line: 52, col: 5, offset: 1209},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 52, col: 7, offset: 1211},
+									label: "variable",
+									expr: &ruleRefExpr{
+										pos:  position{line: 52, col: 16, offset: 1220},
+										name: "Variable",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 52, col: 25, offset: 1229},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 52, col: 27, offset: 1231},
+									label: "comparator",
+									expr: &ruleRefExpr{
+										pos:  position{line: 52, col: 38, offset: 1242},
+										name: "NumComparator",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 52, col: 52, offset: 1256},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 52, col: 54, offset: 1258},
+									label: "value",
+									expr: &ruleRefExpr{
+										pos:  position{line: 52, col: 60, offset: 1264},
 										name: "Number",
 									},
 								},
@@ -234,20 +279,20 @@ var g = &grammar{
 		},
 		{
 			name: "StrComparator",
-			pos:  position{line: 54, col: 1, offset: 1214},
+			pos:  position{line: 61, col: 1, offset: 1454},
 			expr: &actionExpr{
-				pos: position{line: 54, col: 18, offset: 1231},
+				pos: position{line: 61, col: 18, offset: 1471},
 				run: (*parser).callonStrComparator1,
 				expr: &choiceExpr{
-					pos: position{line: 54, col: 19, offset: 1232},
+					pos: position{line: 61, col: 19, offset: 1472},
 					alternatives: []interface{}{
 						&litMatcher{
-							pos:        position{line: 54, col: 19, offset: 1232},
+							pos:        position{line: 61, col: 19, offset: 1472},
 							val:        "==",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 54, col: 26, offset: 1239},
+							pos:        position{line: 61, col: 26, offset: 1479},
 							val:        "!=",
 							ignoreCase: false,
 						},
@@ -257,34 +302,34 @@ var g = &grammar{
 		},
 		{
 			name: "NumComparator",
-			pos:  position{line: 58, col: 1, offset: 1281},
+			pos:  position{line: 65, col: 1, offset: 1521},
 			expr: &actionExpr{
-				pos: position{line: 58, col: 18, offset: 1298},
+				pos: position{line: 65, col: 18, offset: 1538},
 				run: (*parser).callonNumComparator1,
 				expr: &choiceExpr{
-					pos: position{line: 58, col: 19, offset: 1299},
+					pos: position{line: 65, col: 19, offset: 1539},
 					alternatives: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 58, col: 19, offset: 1299},
+							pos:  position{line: 65, col: 19, offset: 1539},
 							name: "StrComparator",
 						},
 						&litMatcher{
-							pos:        position{line: 58, col: 35, offset: 1315},
+							pos:        position{line: 65, col: 35, offset: 1555},
 							val:        ">=",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 58, col: 42, offset: 1322},
+							pos:        position{line: 65, col: 42, offset: 1562},
 							val:        ">",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 58, col: 48, offset: 1328},
+							pos:        position{line: 65, col: 48, offset: 1568},
 							val:        "<=",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 58, col: 55, offset: 1335},
+							pos:        position{line: 65, col: 55, offset: 1575},
 							val:        "<",
 							ignoreCase: false,
 						},
@@ -294,17 +339,17 @@ var g = &grammar{
 		},
 		{
 			name: "Variable",
-			pos:  position{line: 62, col: 1, offset: 1376},
+			pos:  position{line: 69, col: 1, offset: 1616},
 			expr: &actionExpr{
-				pos: position{line: 62, col: 13, offset: 1388},
+				pos: position{line: 69, col: 13, offset: 1628},
 				run: (*parser).callonVariable1,
 				expr: &labeledExpr{
-					pos:   position{line: 62, col: 13, offset: 1388},
+					pos:   position{line: 69, col: 13, offset: 1628},
 					label: "variable",
 					expr: &oneOrMoreExpr{
-						pos: position{line: 62, col: 22, offset: 1397},
+						pos: position{line: 69, col: 22, offset: 1637},
 						expr: &ruleRefExpr{
-							pos:  position{line: 62, col: 22, offset: 1397},
+							pos:  position{line: 69, col: 22, offset: 1637},
 							name: "VarChars",
 						},
 					},
@@ -313,20 +358,20 @@ var g = &grammar{
 		},
 		{
 			name: "Operator",
-			pos:  position{line: 66, col: 1, offset: 1443},
+			pos:  position{line: 73, col: 1, offset: 1683},
 			expr: &actionExpr{
-				pos: position{line: 66, col: 13, offset: 1455},
+				pos: position{line: 73, col: 13, offset: 1695},
 				run: (*parser).callonOperator1,
 				expr: &choiceExpr{
-					pos: position{line: 66, col: 14, offset: 1456},
+					pos: position{line: 73, col: 14, offset: 1696},
 					alternatives: []interface{}{
 						&litMatcher{
-							pos:        position{line: 66, col: 14, offset: 1456},
+							pos:        position{line: 73, col: 14, offset: 1696},
 							val:        "&&",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 66, col: 21, offset: 1463},
+							pos:        position{line: 73, col: 21, offset: 1703},
 							val:        "||",
 							ignoreCase: false,
 						},
@@ -336,39 +381,39 @@ var g = &grammar{
 		},
 		{
 			name: "Number",
-			pos:  position{line: 70, col: 1, offset: 1505},
+			pos:  position{line: 77, col: 1, offset: 1745},
 			expr: &actionExpr{
-				pos: position{line: 70, col: 11, offset: 1515},
+				pos: position{line: 77, col: 11, offset: 1755},
 				run: (*parser).callonNumber1,
 				expr: &seqExpr{
-					pos: position{line: 70, col: 11, offset: 1515},
+					pos: position{line: 77, col: 11, offset: 1755},
 					exprs: []interface{}{
 						&zeroOrOneExpr{
-							pos: position{line: 70, col: 11, offset: 1515},
+							pos: position{line: 77, col: 11, offset: 1755},
 							expr: &litMatcher{
-								pos:        position{line: 70, col: 11, offset: 1515},
+								pos:        position{line: 77, col: 11, offset: 1755},
 								val:        "-",
 								ignoreCase: false,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 70, col: 16, offset: 1520},
+							pos:  position{line: 77, col: 16, offset: 1760},
 							name: "Integer",
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 70, col: 24, offset: 1528},
+							pos: position{line: 77, col: 24, offset: 1768},
 							expr: &seqExpr{
-								pos: position{line: 70, col: 26, offset: 1530},
+								pos: position{line: 77, col: 26, offset: 1770},
 								exprs: []interface{}{
 									&litMatcher{
-										pos:        position{line: 70, col: 26, offset: 1530},
+										pos:        position{line: 77, col: 26, offset: 1770},
 										val:        ".",
 										ignoreCase: false,
 									},
 									&oneOrMoreExpr{
-										pos: position{line: 70, col: 30, offset: 1534},
+										pos: position{line: 77, col: 30, offset: 1774},
 										expr: &ruleRefExpr{
-											pos:  position{line: 70, col: 30, offset: 1534},
+											pos:  position{line: 77, col: 30, offset: 1774},
 											name: "DecimalDigit",
 										},
 									},
@@ -376,9 +421,9 @@ var g = &grammar{
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 70, col: 47, offset: 1551},
+							pos: position{line: 77, col: 47, offset: 1791},
 							expr: &ruleRefExpr{
-								pos:  position{line: 70, col: 47, offset: 1551},
+								pos:  position{line: 77, col: 47, offset: 1791},
 								name: "Exponent",
 							},
 						},
@@ -388,48 +433,48 @@ var g = &grammar{
 		},
 		{
 			name: "String",
-			pos:  position{line: 74, col: 1, offset: 1616},
+			pos:  position{line: 81, col: 1, offset: 1856},
 			expr: &actionExpr{
-				pos: position{line: 74, col: 11, offset: 1626},
+				pos: position{line: 81, col: 11, offset: 1866},
 				run: (*parser).callonString1,
 				expr: &seqExpr{
-					pos: position{line: 74, col: 11, offset: 1626},
+					pos: position{line: 81, col: 11, offset: 1866},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 74, col: 11, offset: 1626},
+							pos:        position{line: 81, col: 11, offset: 1866},
 							val:        "\"",
 							ignoreCase: false,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 74, col: 15, offset: 1630},
+							pos: position{line: 81, col: 15, offset: 1870},
 							expr: &choiceExpr{
-								pos: position{line: 74, col: 17, offset: 1632},
+								pos: position{line: 81, col: 17, offset: 1872},
 								alternatives: []interface{}{
 									&seqExpr{
-										pos: position{line: 74, col: 17, offset: 1632},
+										pos: position{line: 81, col: 17, offset: 1872},
 										exprs: []interface{}{
 											&notExpr{
-												pos: position{line: 74, col: 17, offset: 1632},
+												pos: position{line: 81, col: 17, offset: 1872},
 												expr: &ruleRefExpr{
-													pos:  position{line: 74, col: 18, offset: 1633},
+													pos:  position{line: 81, col: 18, offset: 1873},
 													name: "EscapedChar",
 												},
 											},
 											&anyMatcher{
-												line: 74, col: 30, offset: 1645,
+												line: 81, col: 30, offset: 1885,
 											},
 										},
 									},
 									&seqExpr{
-										pos: position{line: 74, col: 34, offset: 1649},
+										pos: position{line: 81, col: 34, offset: 1889},
 										exprs: []interface{}{
 											&litMatcher{
-												pos:        position{line: 74, col: 34, offset: 1649},
+												pos:        position{line: 81, col: 34, offset: 1889},
 												val:        "\\",
 												ignoreCase: false,
 											},
 											&ruleRefExpr{
-												pos:  position{line: 74, col: 39, offset: 1654},
+												pos:  position{line: 81, col: 39, offset: 1894},
 												name: "EscapeSequence",
 											},
 										},
@@ -438,7 +483,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 74, col: 57, offset: 1672},
+							pos:        position{line: 81, col: 57, offset: 1912},
 							val:        "\"",
 							ignoreCase: false,
 						},
@@ -448,26 +493,26 @@ var g = &grammar{
 		},
 		{
 			name: "Integer",
-			pos:  position{line: 79, col: 1, offset: 1790},
+			pos:  position{line: 86, col: 1, offset: 2030},
 			expr: &choiceExpr{
-				pos: position{line: 79, col: 12, offset: 1801},
+				pos: position{line: 86, col: 12, offset: 2041},
 				alternatives: []interface{}{
 					&litMatcher{
-						pos:        position{line: 79, col: 12, offset: 1801},
+						pos:        position{line: 86, col: 12, offset: 2041},
 						val:        "0",
 						ignoreCase: false,
 					},
 					&seqExpr{
-						pos: position{line: 79, col: 18, offset: 1807},
+						pos: position{line: 86, col: 18, offset: 2047},
 						exprs: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 79, col: 18, offset: 1807},
+								pos:  position{line: 86, col: 18, offset: 2047},
 								name: "NonZeroDecimalDigit",
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 79, col: 38, offset: 1827},
+								pos: position{line: 86, col: 38, offset: 2067},
 								expr: &ruleRefExpr{
-									pos:  position{line: 79, col: 38, offset: 1827},
+									pos:  position{line: 86, col: 38, offset: 2067},
 									name: "DecimalDigit",
 								},
 							},
@@ -478,19 +523,19 @@ var g = &grammar{
 		},
 		{
 			name: "Exponent",
-			pos:  position{line: 80, col: 1, offset: 1841},
+			pos:  position{line: 87, col: 1, offset: 2081},
 			expr: &seqExpr{
-				pos: position{line: 80, col: 13, offset: 1853},
+				pos: position{line: 87, col: 13, offset: 2093},
 				exprs: []interface{}{
 					&litMatcher{
-						pos:        position{line: 80, col: 13, offset: 1853},
+						pos:        position{line: 87, col: 13, offset: 2093},
 						val:        "e",
 						ignoreCase: true,
 					},
 					&zeroOrOneExpr{
-						pos: position{line: 80, col: 18, offset: 1858},
+						pos: position{line: 87, col: 18, offset: 2098},
 						expr: &charClassMatcher{
-							pos:        position{line: 80, col: 18, offset: 1858},
+							pos:        position{line: 87, col: 18, offset: 2098},
 							val:        "[+-]",
 							chars:      []rune{'+', '-'},
 							ignoreCase: false,
@@ -498,9 +543,9 @@ var g = &grammar{
 						},
 					},
 					&oneOrMoreExpr{
-						pos: position{line: 80, col: 24, offset: 1864},
+						pos: position{line: 87, col: 24, offset: 2104},
 						expr: &ruleRefExpr{
-							pos:  position{line: 80, col: 24, offset: 1864},
+							pos:  position{line: 87, col: 24, offset: 2104},
 							name: "DecimalDigit",
 						},
 					},
@@ -509,9 +554,9 @@ var g = &grammar{
 		},
 		{
 			name: "VarChars",
-			pos:  position{line: 81, col: 1, offset: 1878},
+			pos:  position{line: 88, col: 1, offset: 2118},
 			expr: &charClassMatcher{
-				pos:        position{line: 81, col: 13, offset: 1890},
+				pos:        position{line: 88, col: 13, offset: 2130},
 				val:        "[a-z_]",
 				chars:      []rune{'_'},
 				ranges:     []rune{'a', 'z'},
@@ -521,9 +566,9 @@ var g = &grammar{
 		},
 		{
 			name: "EscapedChar",
-			pos:  position{line: 82, col: 1, offset: 1897},
+			pos:  position{line: 89, col: 1, offset: 2137},
 			expr: &charClassMatcher{
-				pos:        position{line: 82, col: 16, offset: 1912},
+				pos:        position{line: 89, col: 16, offset: 2152},
 				val:        "[\\x00-\\x1f\"\\\\]",
 				chars:      []rune{'"', '\\'},
 				ranges:     []rune{'\x00', '\x1f'},
@@ -533,16 +578,16 @@ var g = &grammar{
 		},
 		{
 			name: "EscapeSequence",
-			pos:  position{line: 83, col: 1, offset: 1927},
+			pos:  position{line: 90, col: 1, offset: 2167},
 			expr: &choiceExpr{
-				pos: position{line: 83, col: 19, offset: 1945},
+				pos: position{line: 90, col: 19, offset: 2185},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 83, col: 19, offset: 1945},
+						pos:  position{line: 90, col: 19, offset: 2185},
 						name: "SingleCharEscape",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 83, col: 38, offset: 1964},
+						pos:  position{line: 90, col: 38, offset: 2204},
 						name: "UnicodeEscape",
 					},
 				},
@@ -550,9 +595,9 @@ var g = &grammar{
 		},
 		{
 			name: "SingleCharEscape",
-			pos:  position{line: 84, col: 1, offset: 1978},
+			pos:  position{line: 91, col: 1, offset: 2218},
 			expr: &charClassMatcher{
-				pos:        position{line: 84, col: 21, offset: 1998},
+				pos:        position{line: 91, col: 21, offset: 2238},
 				val:        "[\"\\\\/bfnrt]",
 				chars:      []rune{'"', '\\', '/', 'b', 'f', 'n', 'r', 't'},
 				ignoreCase: false,
@@ -561,29 +606,29 @@ var g = &grammar{
 		},
 		{
 			name: "UnicodeEscape",
-			pos:  position{line: 85, col: 1, offset: 2010},
+			pos:  position{line: 92, col: 1, offset: 2250},
 			expr: &seqExpr{
-				pos: position{line: 85, col: 18, offset: 2027},
+				pos: position{line: 92, col: 18, offset: 2267},
 				exprs: []interface{}{
 					&litMatcher{
-						pos:        position{line: 85, col: 18, offset: 2027},
+						pos:        position{line: 92, col: 18, offset: 2267},
 						val:        "u",
 						ignoreCase: false,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 85, col: 22, offset: 2031},
+						pos:  position{line: 92, col: 22, offset: 2271},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 85, col: 31, offset: 2040},
+						pos:  position{line: 92, col: 31, offset: 2280},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 85, col: 40, offset: 2049},
+						pos:  position{line: 92, col: 40, offset: 2289},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 85, col: 49, offset: 2058},
+						pos:  position{line: 92, col: 49, offset: 2298},
 						name: "HexDigit",
 					},
 				},
@@ -591,9 +636,9 @@ var g = &grammar{
 		},
 		{
 			name: "DecimalDigit",
-			pos:  position{line: 86, col: 1, offset: 2067},
+			pos:  position{line: 93, col: 1, offset: 2307},
 			expr: &charClassMatcher{
-				pos:        position{line: 86, col: 17, offset: 2083},
+				pos:        position{line: 93, col: 17, offset: 2323},
 				val:        "[0-9]",
 				ranges:     []rune{'0', '9'},
 				ignoreCase: false,
@@ -602,9 +647,9 @@ var g = &grammar{
 		},
 		{
 			name: "NonZeroDecimalDigit",
-			pos:  position{line: 87, col: 1, offset: 2089},
+			pos:  position{line: 94, col: 1, offset: 2329},
 			expr: &charClassMatcher{
-				pos:        position{line: 87, col: 24, offset: 2112},
+				pos:        position{line: 94, col: 24, offset: 2352},
 				val:        "[1-9]",
 				ranges:     []rune{'1', '9'},
 				ignoreCase: false,
@@ -613,9 +658,9 @@ var g = &grammar{
 		},
 		{
 			name: "HexDigit",
-			pos:  position{line: 88, col: 1, offset: 2118},
+			pos:  position{line: 95, col: 1, offset: 2358},
 			expr: &charClassMatcher{
-				pos:        position{line: 88, col: 13, offset: 2130},
+				pos:        position{line: 95, col: 13, offset: 2370},
 				val:        "[0-9a-f]i",
 				ranges:     []rune{'0', '9', 'a', 'f'},
 				ignoreCase: true,
@@ -624,24 +669,24 @@ var g = &grammar{
 		},
 		{
 			name: "Bool",
-			pos:  position{line: 89, col: 1, offset: 2140},
+			pos:  position{line: 96, col: 1, offset: 2380},
 			expr: &choiceExpr{
-				pos: position{line: 89, col: 9, offset: 2148},
+				pos: position{line: 96, col: 9, offset: 2388},
 				alternatives: []interface{}{
 					&actionExpr{
-						pos: position{line: 89, col: 9, offset: 2148},
+						pos: position{line: 96, col: 9, offset: 2388},
 						run: (*parser).callonBool2,
 						expr: &litMatcher{
-							pos:        position{line: 89, col: 9, offset: 2148},
+							pos:        position{line: 96, col: 9, offset: 2388},
 							val:        "true",
 							ignoreCase: false,
 						},
 					},
 					&actionExpr{
-						pos: position{line: 89, col: 39, offset: 2178},
+						pos: position{line: 96, col: 39, offset: 2418},
 						run: (*parser).callonBool4,
 						expr: &litMatcher{
-							pos:        position{line: 89, col: 39, offset: 2178},
+							pos:        position{line: 96, col: 39, offset: 2418},
 							val:        "false",
 							ignoreCase: false,
 						},
@@ -652,11 +697,11 @@ var g = &grammar{
 		{
 			name:        "_",
 			displayName: "\"whitespace\"",
-			pos:         position{line: 90, col: 1, offset: 2208},
+			pos:         position{line: 97, col: 1, offset: 2448},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 90, col: 19, offset: 2226},
+				pos: position{line: 97, col: 19, offset: 2466},
 				expr: &charClassMatcher{
-					pos:        position{line: 90, col: 19, offset: 2226},
+					pos:        position{line: 97, col: 19, offset: 2466},
 					val:        "[ \\t\\r\\n]",
 					chars:      []rune{' ', '\t', '\r', '\n'},
 					ignoreCase: false,
@@ -666,11 +711,11 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 92, col: 1, offset: 2238},
+			pos:  position{line: 99, col: 1, offset: 2478},
 			expr: &notExpr{
-				pos: position{line: 92, col: 8, offset: 2245},
+				pos: position{line: 99, col: 8, offset: 2485},
 				expr: &anyMatcher{
-					line: 92, col: 9, offset: 2246,
+					line: 99, col: 9, offset: 2486,
 				},
 			},
 		},
@@ -747,8 +792,8 @@ func (c *current) onComparison13(variable, comparator, value interface{}) (inter
 	return Comparison{
 		Variable:   variable.(string),
 		Comparator: comparator.(string),
-		IsString:   false,
-		NumValue:   value.(float64),
+		IsBool:     true,
+		BoolValue:  value.(bool),
 	}, nil
 }
 
@@ -758,6 +803,21 @@ func (p *parser) callonComparison13() (interface{}, error) {
 	return p.cur.onComparison13(stack["variable"], stack["comparator"], stack["value"])
 }
 
+func (c *current) onComparison24(variable, comparator, value interface{}) (interface{}, error) {
+	return Comparison{
+		Variable:   variable.(string),
+		Comparator: comparator.(string),
+		IsString:   false,
+		NumValue:   value.(float64),
+	}, nil
+}
+
+func (p *parser) callonComparison24() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onComparison24(stack["variable"], stack["comparator"], stack["value"])
+}
+
 func (c *current) onStrComparator1() (interface{}, error) {
 	return string(c.text), nil
 }
@@ -5,8 +5,9 @@ import (
 )
 
 type Value struct {
-	StrValue string
-	NumValue float64
+	StrValue  string
+	NumValue  float64
+	BoolValue bool
 }
 
 type Values map[string]Value
@@ -28,8 +29,10 @@ type Comparison struct {
 	Variable   string
 	Comparator string
 	IsString   bool
+	IsBool     bool
 	StrValue   string
 	NumValue   float64
+	BoolValue  bool
 }
 
 func (e Expression) Evaluate(r *Values) (bool, error) {
@@ -64,7 +67,19 @@ func (c Comparison) Evaluate(r *Values) (bool, error) {
 	var ret bool
 	var err error
 
-	if c.IsString {
+	if c.IsBool {
+		switch c.Comparator {
+		case "==":
+			ret = v.BoolValue == c.BoolValue
+			err = fmt.Errorf("Test Failed: %s(%t) == %t", c.Variable, v.BoolValue, c.BoolValue)
+		case "!=":
+			ret = v.BoolValue != c.BoolValue
+			err = fmt.Errorf("Test Failed: %s(%t) != %t", c.Variable, v.BoolValue, c.BoolValue)
+		default:
+			ret = false
+			err = fmt.Errorf("Bad Comparator")
+		}
+	} else if c.IsString {
 		switch c.Comparator {
 		case "==":
 			ret = v.StrValue == c.StrValue
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing configures the export of OpenTelemetry traces using OTLP over HTTP
+type Tracing struct {
+	Endpoint    *string
+	URLPath     *string `yaml:"url_path"`
+	Insecure    bool
+	Headers     map[string]string
+	ServiceName *string  `yaml:"service_name"`
+	SampleRatio *float64 `yaml:"sample_ratio"`
+}
+
+func (t *Tracing) Check() error {
+	if t.Endpoint == nil {
+		return fmt.Errorf("Tracing.Endpoint cannot be empty")
+	}
+	if t.ServiceName == nil {
+		name := "isup"
+		t.ServiceName = &name
+	}
+	if t.SampleRatio == nil {
+		ratio := 1.0
+		t.SampleRatio = &ratio
+	} else if *t.SampleRatio < 0 || *t.SampleRatio > 1 {
+		return fmt.Errorf("Tracing.SampleRatio must be between 0 and 1")
+	}
+	return nil
+}
+
+// Start sets the global TracerProvider and propagator, returning a function
+// that flushes and stops the TracerProvider. If tracing isn't configured then
+// spans aren't recorded and trace context isn't propagated.
+func Start(ctx context.Context, t *Tracing) (func(context.Context) error, error) {
+	if t == nil {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(*t.Endpoint),
+	}
+	if t.URLPath != nil {
+		opts = append(opts, otlptracehttp.WithURLPath(*t.URLPath))
+	}
+	if t.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(t.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(t.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*t.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(*t.ServiceName),
+		)),
+	)
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warn().Err(err).Msg("Tracing error")
+	}))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}